@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"hornet/api/feed/model"
+	"hornet/api/feed/service"
+	"hornet/common/auth"
+	"hornet/common/httpclient"
+	"hornet/common/logger"
+	"hornet/common/pagination"
+	"hornet/common/problem"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidPostID  = problem.BadRequest("invalid_post_id", "Invalid post ID")
+	errInvalidBody    = problem.BadRequest("invalid_body", "Invalid request body")
+	errInvalidVersion = problem.BadRequest("invalid_version", "Invalid post version")
+)
+
+// GetFeed gets a page of the requesting user's feed, newest first.
+func GetFeed(feedService *service.FeedService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.PrincipalFrom(c)
+		if !ok {
+			problem.Respond(c, auth.ErrUnauthenticated)
+			return
+		}
+		userID := principal.UserID
+
+		page, err := pagination.ParseRequest(c)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		// The followers service is queried on behalf of the user, with their own token
+		ctx := c.Request.Context()
+		if principal.Token != "" {
+			ctx = httpclient.WithBearerToken(ctx, principal.Token)
+		}
+
+		feed, err := feedService.GetFeed(ctx, userID, page)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Feed fetched successfully for user ", userID)
+		c.JSON(http.StatusOK, feed)
+	}
+}
+
+// PublishPost adds a new post to the feeds of its author's followers, or refreshes an edited one.
+// It is called by the posts service, with the service token, with the post as the request body.
+func PublishPost(feedService *service.FeedService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var post model.Post
+		if err := c.ShouldBindJSON(&post); err != nil || post.ID == uuid.Nil || post.AuthorID == uuid.Nil {
+			problem.Respond(c, errInvalidBody)
+			return
+		}
+
+		if err := feedService.PublishPost(c.Request.Context(), post); err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Post published successfully ", post.ID)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RetractPost removes a deleted post from every feed. It is called by the posts service, with the service token,
+// and the version the post took when it was deleted as the version query parameter.
+func RetractPost(feedService *service.FeedService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postIDStr := c.Param("id")
+		postID, err := uuid.Parse(postIDStr)
+		if err != nil {
+			problem.Respond(c, errInvalidPostID)
+			return
+		}
+
+		version, err := strconv.Atoi(c.Query("version"))
+		if err != nil || version < 0 {
+			problem.Respond(c, errInvalidVersion)
+			return
+		}
+
+		if err := feedService.RetractPost(c.Request.Context(), postID, version); err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Post retracted successfully ", postID)
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Post is the feed's snapshot of a post, kept up to date by the posts service when the post
+// is created, edited, deleted or restored. Counters and reactions change too often to be
+// snapshotted, clients read them from the posts service. Updates may arrive out of order,
+// so the snapshot only moves forward in version and a deleted post leaves a tombstone.
+type Post struct {
+	ID             uuid.UUID  `bson:"_id" json:"id"`
+	Content        string     `bson:"content,omitempty" json:"content,omitempty"`
+	AuthorID       uuid.UUID  `bson:"author_id" json:"author_id"`
+	ParentPostID   *uuid.UUID `bson:"-" json:"parent_post_id,omitempty"`                            // Set on replies, which are kept out of feeds
+	OriginalPostID *uuid.UUID `bson:"original_post_id,omitempty" json:"original_post_id,omitempty"` // For shared posts
+	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	EditedAt       *time.Time `bson:"edited_at,omitempty" json:"edited_at,omitempty"`
+	Version        int        `bson:"version" json:"version"`             // The version of the post in the posts service, older updates are ignored
+	Deleted        bool       `bson:"deleted,omitempty" json:"-"`         // Set on the tombstone left by a deleted post, which outlives late updates
+	FannedOut      bool       `bson:"fanned_out" json:"-"`                // Whether the post was written to the followers' timelines, or is read from the author's outbox
+	FanOutPending  bool       `bson:"fan_out_pending,omitempty" json:"-"` // Set until the post reached every follower, so a publish sent again resumes an interrupted fan-out
+}
+
+// TimelineEntry is a post written to the timeline of one of its author's followers.
+type TimelineEntry struct {
+	UserID    uuid.UUID `bson:"user_id"`    // The follower owning the timeline
+	PostID    uuid.UUID `bson:"post_id"`    // The post, whose snapshot holds its content
+	AuthorID  uuid.UUID `bson:"author_id"`  // The author of the post, to drop entries after an unfollow
+	CreatedAt time.Time `bson:"created_at"` // The creation time of the post, which orders the timeline
+}
+
+// FeedPage represents one page of a user's feed, newest first.
+type FeedPage struct {
+	Posts      []Post `json:"posts"`
+	NextCursor string `json:"next_cursor,omitempty"` // Pass as `after` to fetch the following page
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	followersclient "hornet/api/followers/client"
+	followersmodel "hornet/api/followers/model"
+	"hornet/common/httpclient"
+
+	"github.com/google/uuid"
+)
+
+// maxRelationshipsBatch is the largest number of users the followers service looks up at once
+const maxRelationshipsBatch = 100
+
+// FollowersRepository reads the parts of the social graph the feeds depend on
+type FollowersRepository interface {
+	// GetFollowersCount retrieves the number of followers of a user
+	GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// ForEachFollowersPage calls fn with the IDs of the followers of a user, one page at a time
+	ForEachFollowersPage(ctx context.Context, userID uuid.UUID, fn func(followerIDs []uuid.UUID) error) error
+
+	// ForEachFollowingPage calls fn with the IDs of the users a user follows, one page at a time
+	ForEachFollowingPage(ctx context.Context, userID uuid.UUID, fn func(followeeIDs []uuid.UUID) error) error
+
+	// GetFollowedUsers retrieves which of the given users a user follows
+	GetFollowedUsers(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+
+	// GetMutes retrieves the active mutes of a user
+	GetMutes(ctx context.Context, userID uuid.UUID) (followersmodel.Mutes, error)
+}
+
+// HTTPFollowersRepository reads the social graph from the followers service, which owns it
+type HTTPFollowersRepository struct {
+	followers *followersclient.Client
+}
+
+// NewHTTPFollowersRepository creates an HTTPFollowersRepository for the followers service at baseURL
+func NewHTTPFollowersRepository(baseURL string) *HTTPFollowersRepository {
+	return &HTTPFollowersRepository{
+		followers: followersclient.New(baseURL, nil),
+	}
+}
+
+// GetFollowersCount retrieves the number of followers of a user
+func (r *HTTPFollowersRepository) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	return r.followers.GetFollowersCount(ctx, userID)
+}
+
+// ForEachFollowersPage calls fn with the IDs of the followers of a user, one page at a time
+func (r *HTTPFollowersRepository) ForEachFollowersPage(ctx context.Context, userID uuid.UUID, fn func(followerIDs []uuid.UUID) error) error {
+	page := httpclient.Page{Limit: maxRelationshipsBatch}
+
+	for {
+		follows, err := r.followers.GetFollowers(ctx, userID, page)
+		if err != nil {
+			return err
+		}
+
+		followerIDs := make([]uuid.UUID, 0, len(follows.Follows))
+		for _, follow := range follows.Follows {
+			followerIDs = append(followerIDs, follow.SenderID)
+		}
+		if err := fn(followerIDs); err != nil {
+			return err
+		}
+
+		if follows.NextCursor == "" {
+			return nil
+		}
+		page.After = follows.NextCursor
+	}
+}
+
+// ForEachFollowingPage calls fn with the IDs of the users a user follows, one page at a time
+func (r *HTTPFollowersRepository) ForEachFollowingPage(ctx context.Context, userID uuid.UUID, fn func(followeeIDs []uuid.UUID) error) error {
+	page := httpclient.Page{Limit: maxRelationshipsBatch}
+
+	for {
+		follows, err := r.followers.GetFollowing(ctx, userID, page)
+		if err != nil {
+			return err
+		}
+
+		followeeIDs := make([]uuid.UUID, 0, len(follows.Follows))
+		for _, follow := range follows.Follows {
+			followeeIDs = append(followeeIDs, follow.ReceiverID)
+		}
+		if err := fn(followeeIDs); err != nil {
+			return err
+		}
+
+		if follows.NextCursor == "" {
+			return nil
+		}
+		page.After = follows.NextCursor
+	}
+}
+
+// GetFollowedUsers retrieves which of the given users a user follows
+func (r *HTTPFollowersRepository) GetFollowedUsers(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	followed := make(map[uuid.UUID]bool, len(otherIDs))
+	ctx = httpclient.WithUserID(ctx, userID)
+
+	for start := 0; start < len(otherIDs); start += maxRelationshipsBatch {
+		end := min(start+maxRelationshipsBatch, len(otherIDs))
+
+		relationships, err := r.followers.GetRelationships(ctx, otherIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, relationship := range relationships {
+			if relationship.Following {
+				followed[relationship.UserID] = true
+			}
+		}
+	}
+
+	return followed, nil
+}
+
+// GetMutes retrieves the active mutes of a user
+func (r *HTTPFollowersRepository) GetMutes(ctx context.Context, userID uuid.UUID) (followersmodel.Mutes, error) {
+	return r.followers.GetMutes(httpclient.WithUserID(ctx, userID))
+}
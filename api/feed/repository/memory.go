@@ -0,0 +1,362 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"hornet/api/feed/model"
+	followersmodel "hornet/api/followers/model"
+	"hornet/common/pagination"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// MemoryFeedRepository stores the feeds in memory. It is safe for concurrent use.
+type MemoryFeedRepository struct {
+	mu            sync.RWMutex
+	posts         map[uuid.UUID]model.Post
+	fanOut        map[uuid.UUID]bool // The fan-out decision of each post, absent until it is taken
+	timelines     map[uuid.UUID]map[uuid.UUID]model.TimelineEntry
+	outboxAuthors map[uuid.UUID]bool
+}
+
+// NewMemoryFeedRepository creates an empty MemoryFeedRepository
+func NewMemoryFeedRepository() *MemoryFeedRepository {
+	return &MemoryFeedRepository{
+		posts:         make(map[uuid.UUID]model.Post),
+		fanOut:        make(map[uuid.UUID]bool),
+		timelines:     make(map[uuid.UUID]map[uuid.UUID]model.TimelineEntry),
+		outboxAuthors: make(map[uuid.UUID]bool),
+	}
+}
+
+// UpsertPost stores the snapshot of a post unless the stored snapshot is at the same or a newer version.
+// A new post is stored with its fan-out pending, an edit keeps the fan-out state of the post.
+func (r *MemoryFeedRepository) UpsertPost(ctx context.Context, post model.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.posts[post.ID]
+	if ok && previous.Version >= post.Version {
+		return nil
+	}
+
+	post.Deleted = false
+	post.ParentPostID = nil
+	post.FannedOut = false
+	post.FanOutPending = !ok || previous.FanOutPending
+	r.posts[post.ID] = clonePost(post)
+	return nil
+}
+
+// TombstonePost replaces the snapshot of a deleted post with a tombstone at the given version,
+// unless a newer version is stored. The fan-out of the post is reset, for a restore to take it again.
+// It reports whether it stored the tombstone.
+func (r *MemoryFeedRepository) TombstonePost(ctx context.Context, postID uuid.UUID, version int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.posts[postID]
+	if ok && (current.Version > version || current.Version == version && current.Deleted) {
+		return false, nil
+	}
+
+	current.ID = postID
+	current.Content = ""
+	current.EditedAt = nil
+	current.Version = version
+	current.Deleted = true
+	current.FanOutPending = true
+	r.posts[postID] = current
+	delete(r.fanOut, postID)
+	return true, nil
+}
+
+// FindPost retrieves the snapshot of a post, or nil when the post is unknown
+func (r *MemoryFeedRepository) FindPost(ctx context.Context, postID uuid.UUID) (*model.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	post, ok := r.posts[postID]
+	if !ok {
+		return nil, nil
+	}
+	snapshot := r.snapshot(post)
+	return &snapshot, nil
+}
+
+// DecideFanOut records whether a post is written to the followers' timelines, or read from its
+// author's outbox, unless an earlier publish decided it already. It returns the decision that stands.
+func (r *MemoryFeedRepository) DecideFanOut(ctx context.Context, postID uuid.UUID, fannedOut bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.posts[postID]; !ok {
+		return fannedOut, nil
+	}
+	if decided, ok := r.fanOut[postID]; ok {
+		return decided, nil
+	}
+	r.fanOut[postID] = fannedOut
+	return fannedOut, nil
+}
+
+// CompleteFanOut records that a post reached every follower, unless its snapshot moved past
+// the given version or became a tombstone since
+func (r *MemoryFeedRepository) CompleteFanOut(ctx context.Context, postID uuid.UUID, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if post, ok := r.posts[postID]; ok && post.Version == version && !post.Deleted {
+		post.FanOutPending = false
+		r.posts[postID] = post
+	}
+	return nil
+}
+
+// AddTimelineEntries writes a post to the timelines of a batch of followers, skipping the
+// timelines that already hold it
+func (r *MemoryFeedRepository) AddTimelineEntries(ctx context.Context, entries []model.TimelineEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range entries {
+		timeline, ok := r.timelines[entry.UserID]
+		if !ok {
+			timeline = make(map[uuid.UUID]model.TimelineEntry)
+			r.timelines[entry.UserID] = timeline
+		}
+		if _, ok := timeline[entry.PostID]; !ok {
+			timeline[entry.PostID] = entry
+		}
+	}
+	return nil
+}
+
+// RemoveTimelineEntries removes a post from every timeline
+func (r *MemoryFeedRepository) RemoveTimelineEntries(ctx context.Context, postID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, timeline := range r.timelines {
+		delete(timeline, postID)
+	}
+	return nil
+}
+
+// AddOutboxAuthor records an author whose posts are read from their outbox
+func (r *MemoryFeedRepository) AddOutboxAuthor(ctx context.Context, authorID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outboxAuthors[authorID] = true
+	return nil
+}
+
+// FindOutboxAuthors retrieves which of the given authors have their posts read from their outbox
+func (r *MemoryFeedRepository) FindOutboxAuthors(ctx context.Context, authorIDs []uuid.UUID) ([]uuid.UUID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var outboxAuthors []uuid.UUID
+	for _, authorID := range authorIDs {
+		if r.outboxAuthors[authorID] {
+			outboxAuthors = append(outboxAuthors, authorID)
+		}
+	}
+	return outboxAuthors, nil
+}
+
+// FindFeedPage retrieves the limit posts following the cursor in a user's feed, newest first,
+// merging the user's timeline with the live posts in the outboxes of the given authors.
+// It reports whether more posts exist beyond the page.
+func (r *MemoryFeedRepository) FindFeedPage(ctx context.Context, userID uuid.UUID, outboxAuthors []uuid.UUID, after *pagination.Cursor, limit int) ([]model.Post, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	outbox := make(map[uuid.UUID]bool, len(outboxAuthors))
+	for _, authorID := range outboxAuthors {
+		outbox[authorID] = true
+	}
+
+	// Timeline entries point at their snapshot whatever its state, like the lookup of the MongoDB pipeline
+	var posts []model.Post
+	for postID := range r.timelines[userID] {
+		if post, ok := r.posts[postID]; ok {
+			posts = append(posts, r.snapshot(post))
+		}
+	}
+	for postID, post := range r.posts {
+		fannedOut, decided := r.fanOut[postID]
+		if outbox[post.AuthorID] && decided && !fannedOut && !post.Deleted {
+			posts = append(posts, r.snapshot(post))
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return newerPost(posts[i], posts[j])
+	})
+
+	page := []model.Post{}
+	for _, post := range posts {
+		if after != nil && !newerPost(model.Post{ID: after.ID, CreatedAt: after.CreatedAt}, post) {
+			continue
+		}
+		page = append(page, post)
+	}
+
+	hasMore := len(page) > limit
+	if hasMore {
+		page = page[:limit]
+	}
+	return page, hasMore, nil
+}
+
+// snapshot returns a copy of a stored post carrying its fan-out decision
+func (r *MemoryFeedRepository) snapshot(post model.Post) model.Post {
+	post.FannedOut = r.fanOut[post.ID]
+	return clonePost(post)
+}
+
+// newerPost reports whether a comes before b in a feed, newest first
+func newerPost(a, b model.Post) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) > 0
+}
+
+// clonePost returns a copy of a post that shares no memory with it
+func clonePost(post model.Post) model.Post {
+	if post.OriginalPostID != nil {
+		originalPostID := *post.OriginalPostID
+		post.OriginalPostID = &originalPostID
+	}
+	if post.EditedAt != nil {
+		editedAt := *post.EditedAt
+		post.EditedAt = &editedAt
+	}
+	return post
+}
+
+// MemoryFollowersRepository holds a social graph in memory, for running the feeds without
+// the followers service. It is safe for concurrent use.
+type MemoryFollowersRepository struct {
+	mu        sync.RWMutex
+	following map[uuid.UUID]map[uuid.UUID]bool // The users each user follows
+	mutes     map[uuid.UUID]followersmodel.Mutes
+}
+
+// NewMemoryFollowersRepository creates an empty MemoryFollowersRepository
+func NewMemoryFollowersRepository() *MemoryFollowersRepository {
+	return &MemoryFollowersRepository{
+		following: make(map[uuid.UUID]map[uuid.UUID]bool),
+		mutes:     make(map[uuid.UUID]followersmodel.Mutes),
+	}
+}
+
+// Follow makes the sender follow the receiver
+func (r *MemoryFollowersRepository) Follow(senderID, receiverID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	followees, ok := r.following[senderID]
+	if !ok {
+		followees = make(map[uuid.UUID]bool)
+		r.following[senderID] = followees
+	}
+	followees[receiverID] = true
+}
+
+// Unfollow makes the sender stop following the receiver
+func (r *MemoryFollowersRepository) Unfollow(senderID, receiverID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.following[senderID], receiverID)
+}
+
+// SetMutes replaces the active mutes of a user
+func (r *MemoryFollowersRepository) SetMutes(userID uuid.UUID, mutes followersmodel.Mutes) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.mutes[userID] = mutes
+}
+
+// GetFollowersCount retrieves the number of followers of a user
+func (r *MemoryFollowersRepository) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, followees := range r.following {
+		if followees[userID] {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ForEachFollowersPage calls fn with the IDs of the followers of a user, one page at a time
+func (r *MemoryFollowersRepository) ForEachFollowersPage(ctx context.Context, userID uuid.UUID, fn func(followerIDs []uuid.UUID) error) error {
+	r.mu.RLock()
+	var followerIDs []uuid.UUID
+	for followerID, followees := range r.following {
+		if followees[userID] {
+			followerIDs = append(followerIDs, followerID)
+		}
+	}
+	r.mu.RUnlock()
+
+	return forEachPage(followerIDs, fn)
+}
+
+// ForEachFollowingPage calls fn with the IDs of the users a user follows, one page at a time
+func (r *MemoryFollowersRepository) ForEachFollowingPage(ctx context.Context, userID uuid.UUID, fn func(followeeIDs []uuid.UUID) error) error {
+	r.mu.RLock()
+	followeeIDs := make([]uuid.UUID, 0, len(r.following[userID]))
+	for followeeID := range r.following[userID] {
+		followeeIDs = append(followeeIDs, followeeID)
+	}
+	r.mu.RUnlock()
+
+	return forEachPage(followeeIDs, fn)
+}
+
+// forEachPage calls fn with the IDs in pages of maxRelationshipsBatch, the way the followers service pages them
+func forEachPage(ids []uuid.UUID, fn func(ids []uuid.UUID) error) error {
+	for start := 0; start < len(ids); start += maxRelationshipsBatch {
+		end := min(start+maxRelationshipsBatch, len(ids))
+		if err := fn(ids[start:end]); err != nil {
+			return err
+		}
+	}
+	if len(ids) == 0 {
+		return fn(nil)
+	}
+	return nil
+}
+
+// GetFollowedUsers retrieves which of the given users a user follows
+func (r *MemoryFollowersRepository) GetFollowedUsers(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	followed := make(map[uuid.UUID]bool, len(otherIDs))
+	for _, otherID := range otherIDs {
+		if r.following[userID][otherID] {
+			followed[otherID] = true
+		}
+	}
+	return followed, nil
+}
+
+// GetMutes retrieves the active mutes of a user
+func (r *MemoryFollowersRepository) GetMutes(ctx context.Context, userID uuid.UUID) (followersmodel.Mutes, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.mutes[userID], nil
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"hornet/api/feed/model"
+	"hornet/common/pagination"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// upsertAttempts bounds the upserts of a snapshot, as two upserts inserting the same new post
+// race on its ID and the loser must retry as an update
+const upsertAttempts = 2
+
+// FeedRepository stores the feeds: the snapshots of the posts, the timelines of the users
+// the posts were fanned out to, and the authors whose posts are read from their outbox
+type FeedRepository interface {
+	// UpsertPost stores the snapshot of a post unless the stored snapshot is at the same or a newer version.
+	// A new post is stored with its fan-out pending, an edit keeps the fan-out state of the post.
+	UpsertPost(ctx context.Context, post model.Post) error
+
+	// TombstonePost replaces the snapshot of a deleted post with a tombstone at the given version,
+	// unless a newer version is stored. A tombstone wins over a live snapshot of the same version.
+	// The fan-out of the post is reset, for a restore to take it again. It reports whether it stored the tombstone.
+	TombstonePost(ctx context.Context, postID uuid.UUID, version int) (bool, error)
+
+	// FindPost retrieves the snapshot of a post, or nil when the post is unknown
+	FindPost(ctx context.Context, postID uuid.UUID) (*model.Post, error)
+
+	// DecideFanOut records whether a post is written to the followers' timelines, or read from its
+	// author's outbox, unless an earlier publish decided it already. It returns the decision that stands.
+	DecideFanOut(ctx context.Context, postID uuid.UUID, fannedOut bool) (bool, error)
+
+	// CompleteFanOut records that a post reached every follower, unless its snapshot moved past
+	// the given version or became a tombstone since
+	CompleteFanOut(ctx context.Context, postID uuid.UUID, version int) error
+
+	// AddTimelineEntries writes a post to the timelines of a batch of followers, skipping the
+	// timelines that already hold it
+	AddTimelineEntries(ctx context.Context, entries []model.TimelineEntry) error
+
+	// RemoveTimelineEntries removes a post from every timeline
+	RemoveTimelineEntries(ctx context.Context, postID uuid.UUID) error
+
+	// AddOutboxAuthor records an author whose posts are read from their outbox.
+	// Authors stay recorded, so their older outbox posts remain visible.
+	AddOutboxAuthor(ctx context.Context, authorID uuid.UUID) error
+
+	// FindOutboxAuthors retrieves which of the given authors have their posts read from their outbox
+	FindOutboxAuthors(ctx context.Context, authorIDs []uuid.UUID) ([]uuid.UUID, error)
+
+	// FindFeedPage retrieves the limit posts following the cursor in a user's feed, newest first,
+	// merging the user's timeline with the live posts in the outboxes of the given authors.
+	// It reports whether more posts exist beyond the page.
+	FindFeedPage(ctx context.Context, userID uuid.UUID, outboxAuthors []uuid.UUID, after *pagination.Cursor, limit int) ([]model.Post, bool, error)
+}
+
+// MongoFeedRepository stores the feeds in MongoDB
+type MongoFeedRepository struct {
+	Posts         *mongo.Collection // Snapshots of the posts shown in feeds
+	Timelines     *mongo.Collection // Posts fanned out to each follower when they were written
+	OutboxAuthors *mongo.Collection // Authors whose posts are read from their outbox instead
+}
+
+// NewMongoFeedRepository creates a MongoFeedRepository storing its collections in db
+func NewMongoFeedRepository(db *mongo.Database) *MongoFeedRepository {
+	return &MongoFeedRepository{
+		Posts:         db.Collection("feed_posts"),
+		Timelines:     db.Collection("feed_timelines"),
+		OutboxAuthors: db.Collection("feed_outbox_authors"),
+	}
+}
+
+// EnsureIndexes creates the indexes the repository queries rely on
+func (r *MongoFeedRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Timelines.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Serves the newest-first timeline at the same cost for every page
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "post_id", Value: -1}},
+			Options: options.Index().SetName("user_created_at"),
+		},
+		{
+			// A post is written to a timeline once, so a repeated fan-out is harmless
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "post_id", Value: 1}},
+			Options: options.Index().SetName("user_post").SetUnique(true),
+		},
+		{
+			// Serves removing a deleted post from every timeline
+			Keys:    bson.D{{Key: "post_id", Value: 1}},
+			Options: options.Index().SetName("post_id"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Posts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Serves reading the outboxes of the followed authors, newest first
+		Keys:    bson.D{{Key: "author_id", Value: 1}, {Key: "fanned_out", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		Options: options.Index().SetName("author_outbox"),
+	})
+	return err
+}
+
+// UpsertPost stores the snapshot of a post unless the stored snapshot is at the same or a newer version.
+// A new post is stored with its fan-out pending, an edit keeps the fan-out state of the post.
+func (r *MongoFeedRepository) UpsertPost(ctx context.Context, post model.Post) error {
+	filter := bson.M{"_id": post.ID, "version": bson.M{"$lt": post.Version}}
+
+	set := bson.M{
+		"content":    post.Content,
+		"author_id":  post.AuthorID,
+		"created_at": post.CreatedAt,
+		"version":    post.Version,
+	}
+	unset := bson.M{"deleted": ""}
+	if post.OriginalPostID != nil {
+		set["original_post_id"] = post.OriginalPostID
+	}
+	if post.EditedAt != nil {
+		set["edited_at"] = post.EditedAt
+	} else {
+		unset["edited_at"] = ""
+	}
+
+	// The fan-out state is left untouched, it is taken once the post is stored
+	update := bson.M{"$set": set, "$unset": unset, "$setOnInsert": bson.M{"fan_out_pending": true}}
+	_, err := r.upsert(ctx, filter, update)
+	return err
+}
+
+// TombstonePost replaces the snapshot of a deleted post with a tombstone at the given version, so
+// publishes of older versions that arrive late are ignored. A tombstone wins over a live snapshot
+// of the same version. The fan-out of the post is reset, for a restore to take it again.
+// It reports whether it stored the tombstone.
+func (r *MongoFeedRepository) TombstonePost(ctx context.Context, postID uuid.UUID, version int) (bool, error) {
+	filter := bson.M{"_id": postID, "$or": bson.A{
+		bson.M{"version": bson.M{"$lt": version}},
+		bson.M{"version": version, "deleted": bson.M{"$ne": true}},
+	}}
+	update := bson.M{
+		"$set":   bson.M{"version": version, "deleted": true, "fan_out_pending": true},
+		"$unset": bson.M{"content": "", "edited_at": "", "fanned_out": ""},
+	}
+
+	return r.upsert(ctx, filter, update)
+}
+
+// upsert applies an update to the snapshot matched by filter, or inserts it when the post is new.
+// A post that exists but does not match is newer, and the update is skipped.
+// It reports whether it applied the update.
+func (r *MongoFeedRepository) upsert(ctx context.Context, filter, update bson.M) (bool, error) {
+	var err error
+	for attempt := 0; attempt < upsertAttempts; attempt++ {
+		_, err = r.Posts.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err == nil {
+			return true, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return false, err
+		}
+	}
+
+	// The post exists without matching the filter, a newer version is stored
+	return false, nil
+}
+
+// FindPost retrieves the snapshot of a post, or nil when the post is unknown
+func (r *MongoFeedRepository) FindPost(ctx context.Context, postID uuid.UUID) (*model.Post, error) {
+	var post model.Post
+	err := r.Posts.FindOne(ctx, bson.M{"_id": postID}).Decode(&post)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// DecideFanOut records whether a post is written to the followers' timelines, or read from its
+// author's outbox, unless an earlier publish decided it already. It returns the decision that stands.
+func (r *MongoFeedRepository) DecideFanOut(ctx context.Context, postID uuid.UUID, fannedOut bool) (bool, error) {
+	filter := bson.M{"_id": postID, "fanned_out": bson.M{"$exists": false}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var post model.Post
+	err := r.Posts.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"fanned_out": fannedOut}}, opts).Decode(&post)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Decided already, or unknown
+		stored, err := r.FindPost(ctx, postID)
+		if err != nil || stored == nil {
+			return fannedOut, err
+		}
+		return stored.FannedOut, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return post.FannedOut, nil
+}
+
+// CompleteFanOut records that a post reached every follower, unless its snapshot moved past
+// the given version or became a tombstone since
+func (r *MongoFeedRepository) CompleteFanOut(ctx context.Context, postID uuid.UUID, version int) error {
+	filter := bson.M{"_id": postID, "version": version, "deleted": bson.M{"$ne": true}}
+	_, err := r.Posts.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"fan_out_pending": ""}})
+	return err
+}
+
+// RemoveTimelineEntries removes a post from every timeline
+func (r *MongoFeedRepository) RemoveTimelineEntries(ctx context.Context, postID uuid.UUID) error {
+	_, err := r.Timelines.DeleteMany(ctx, bson.M{"post_id": postID})
+	return err
+}
+
+// AddTimelineEntries writes a post to the timelines of a batch of followers, skipping the
+// timelines that already hold it
+func (r *MongoFeedRepository) AddTimelineEntries(ctx context.Context, entries []model.TimelineEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(entries))
+	for _, entry := range entries {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": entry.UserID, "post_id": entry.PostID}).
+			SetUpdate(bson.M{"$setOnInsert": entry}).
+			SetUpsert(true))
+	}
+
+	_, err := r.Timelines.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// AddOutboxAuthor records an author whose posts are read from their outbox.
+// Authors stay recorded, so their older outbox posts remain visible.
+func (r *MongoFeedRepository) AddOutboxAuthor(ctx context.Context, authorID uuid.UUID) error {
+	filter := bson.M{"_id": authorID}
+	update := bson.M{"$setOnInsert": bson.M{"_id": authorID}}
+
+	_, err := r.OutboxAuthors.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// FindOutboxAuthors retrieves which of the given authors have their posts read from their outbox
+func (r *MongoFeedRepository) FindOutboxAuthors(ctx context.Context, authorIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if len(authorIDs) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.OutboxAuthors.Find(ctx, bson.M{"_id": bson.M{"$in": authorIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var authors []struct {
+		ID uuid.UUID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &authors); err != nil {
+		return nil, err
+	}
+
+	outboxAuthors := make([]uuid.UUID, 0, len(authors))
+	for _, author := range authors {
+		outboxAuthors = append(outboxAuthors, author.ID)
+	}
+	return outboxAuthors, nil
+}
+
+// FindFeedPage retrieves the limit posts following the cursor in a user's feed, newest first,
+// merging the user's timeline with the outboxes of the given authors.
+// It reports whether more posts exist beyond the page.
+func (r *MongoFeedRepository) FindFeedPage(ctx context.Context, userID uuid.UUID, outboxAuthors []uuid.UUID, after *pagination.Cursor, limit int) ([]model.Post, bool, error) {
+	sort := bson.D{{Key: "created_at", Value: -1}, {Key: "post_id", Value: -1}}
+
+	timeline := bson.M{"user_id": userID}
+	outbox := bson.M{"author_id": bson.M{"$in": outboxAuthors}, "fanned_out": false, "deleted": bson.M{"$ne": true}}
+	if after != nil {
+		timeline["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "post_id": bson.M{"$lt": after.ID}},
+		}
+		outbox["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "_id": bson.M{"$lt": after.ID}},
+		}
+	}
+
+	// Each source is cut to one extra post before merging, to find out whether another page exists
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: timeline}},
+		{{Key: "$sort", Value: sort}},
+		{{Key: "$limit", Value: limit + 1}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "post_id": 1, "created_at": 1}}},
+	}
+	if len(outboxAuthors) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$unionWith", Value: bson.M{
+			"coll": r.Posts.Name(),
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: outbox}},
+				{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}}},
+				{{Key: "$limit", Value: limit + 1}},
+				{{Key: "$project", Value: bson.M{"_id": 0, "post_id": "$_id", "created_at": 1}}},
+			},
+		}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: sort}},
+		bson.D{{Key: "$limit", Value: limit + 1}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         r.Posts.Name(),
+			"localField":   "post_id",
+			"foreignField": "_id",
+			"as":           "post",
+		}}},
+		bson.D{{Key: "$unwind", Value: "$post"}},
+		bson.D{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$post"}}},
+	)
+
+	cursor, err := r.Timelines.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, false, err
+	}
+	defer cursor.Close(ctx)
+
+	posts := []model.Post{}
+	if err := cursor.All(ctx, &posts); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+	return posts, hasMore, nil
+}
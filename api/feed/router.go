@@ -0,0 +1,31 @@
+package feed
+
+import (
+	"hornet/api/feed/handler"
+	"hornet/api/feed/service"
+	"hornet/common/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Router sets up the Gin router with all the routes
+func Router(feedService *service.FeedService, authenticator *auth.Authenticator) *gin.Engine {
+	r := gin.Default()
+
+	// Put the authenticated user, if any, into the context of every request
+	r.Use(authenticator.Middleware())
+
+	// Get the user's feed
+	r.GET("/feed", handler.GetFeed(feedService))
+
+	// Internal routes called by the posts service with the service token, not exposed by the gateway
+	internal := r.Group("/internal", auth.RequireService())
+
+	// Publish a new or edited post to the feeds
+	internal.POST("/feed/posts", handler.PublishPost(feedService))
+
+	// Retract a deleted post from the feeds
+	internal.DELETE("/feed/posts/:id", handler.RetractPost(feedService))
+
+	return r
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hornet/api/feed/model"
+	"hornet/api/feed/repository"
+	"hornet/common/pagination"
+	"hornet/common/problem"
+
+	"github.com/google/uuid"
+)
+
+// maxFeedScans bounds how many store pages one feed page may read while skipping muted
+// and unfollowed posts, so a feed full of them still answers quickly with a cursor
+const maxFeedScans = 5
+
+// FeedService defines the methods for handling feed-related business logic
+type FeedService struct {
+	feedRepository      repository.FeedRepository
+	followersRepository repository.FollowersRepository
+	options             Options
+}
+
+// Options holds the tunable business rules of the FeedService
+type Options struct {
+	FanOutThreshold int // Authors with more followers than this are read from their outbox instead of fanned out
+}
+
+var (
+	// ErrBackwardNotSupported is returned when paging backward through a feed
+	ErrBackwardNotSupported = problem.BadRequest("backward_not_supported", "before is not supported for feeds")
+
+	// ErrMissingUser is returned when reading a feed without naming its user
+	ErrMissingUser = problem.Validation("missing_user_id", "user_id", "A feed belongs to a user")
+)
+
+// NewFeedService creates a FeedService over the given repositories
+func NewFeedService(feedRepository repository.FeedRepository, followersRepository repository.FollowersRepository, options Options) *FeedService {
+	return &FeedService{
+		feedRepository:      feedRepository,
+		followersRepository: followersRepository,
+		options:             options,
+	}
+}
+
+// PublishPost adds a new or restored post to the feeds of its author's followers, or refreshes
+// the snapshot of a known post after an edit. Replies are not part of feeds and are ignored,
+// as are versions older than the stored snapshot, which a later update already replaced.
+//
+// The posts service sends a post again until it gets an answer, so a publish of the stored
+// version resumes a fan-out that was cut short, and is ignored once the fan-out completed.
+func (s *FeedService) PublishPost(ctx context.Context, post model.Post) error {
+	if post.ParentPostID != nil {
+		return nil
+	}
+
+	if err := s.feedRepository.UpsertPost(ctx, post); err != nil {
+		return fmt.Errorf("failed to save post with ID %s: %w", post.ID, err)
+	}
+
+	stored, err := s.feedRepository.FindPost(ctx, post.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get post with ID %s: %w", post.ID, err)
+	}
+	if stored == nil || stored.Deleted || stored.Version != post.Version || !stored.FanOutPending {
+		// Stale, retracted, or already in the feeds
+		return nil
+	}
+
+	followersCount, err := s.followersRepository.GetFollowersCount(ctx, post.AuthorID)
+	if err != nil {
+		return fmt.Errorf("failed to get followers count of author %s: %w", post.AuthorID, err)
+	}
+
+	// Writing to every follower's timeline is too costly for the largest accounts,
+	// their followers read the post from the author's outbox instead
+	fannedOut := followersCount <= s.options.FanOutThreshold
+	if !fannedOut {
+		if err := s.feedRepository.AddOutboxAuthor(ctx, post.AuthorID); err != nil {
+			return fmt.Errorf("failed to record outbox author %s: %w", post.AuthorID, err)
+		}
+	}
+
+	// A resumed fan-out keeps the decision of the first attempt
+	fannedOut, err = s.feedRepository.DecideFanOut(ctx, post.ID, fannedOut)
+	if err != nil {
+		return fmt.Errorf("failed to save post with ID %s: %w", post.ID, err)
+	}
+
+	if fannedOut {
+		if err := s.fanOut(ctx, post); err != nil {
+			return err
+		}
+	}
+
+	if err := s.feedRepository.CompleteFanOut(ctx, post.ID, post.Version); err != nil {
+		return fmt.Errorf("failed to save post with ID %s: %w", post.ID, err)
+	}
+
+	// A retract landing during the fan-out only removed the entries written before it
+	stored, err = s.feedRepository.FindPost(ctx, post.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get post with ID %s: %w", post.ID, err)
+	}
+	if stored != nil && stored.Deleted {
+		if err := s.feedRepository.RemoveTimelineEntries(ctx, post.ID); err != nil {
+			return fmt.Errorf("failed to delete post with ID %s: %w", post.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// fanOut writes a post to the timelines of every follower of its author.
+// Timelines that already hold the post are skipped, so it can run again after a failure.
+func (s *FeedService) fanOut(ctx context.Context, post model.Post) error {
+	err := s.followersRepository.ForEachFollowersPage(ctx, post.AuthorID, func(followerIDs []uuid.UUID) error {
+		entries := make([]model.TimelineEntry, 0, len(followerIDs))
+		for _, followerID := range followerIDs {
+			entries = append(entries, model.TimelineEntry{
+				UserID:    followerID,
+				PostID:    post.ID,
+				AuthorID:  post.AuthorID,
+				CreatedAt: post.CreatedAt,
+			})
+		}
+		return s.feedRepository.AddTimelineEntries(ctx, entries)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fan out post with ID %s: %w", post.ID, err)
+	}
+	return nil
+}
+
+// RetractPost removes a deleted post from every feed, leaving a tombstone at the given version
+// so late publishes of the post are ignored. A newer version of the post, restored since, stays.
+func (s *FeedService) RetractPost(ctx context.Context, postID uuid.UUID, version int) error {
+	retracted, err := s.feedRepository.TombstonePost(ctx, postID, version)
+	if err != nil {
+		return fmt.Errorf("failed to delete post with ID %s: %w", postID, err)
+	}
+	if !retracted {
+		return nil
+	}
+
+	if err := s.feedRepository.RemoveTimelineEntries(ctx, postID); err != nil {
+		return fmt.Errorf("failed to delete post with ID %s: %w", postID, err)
+	}
+
+	return nil
+}
+
+// GetFeed retrieves one page of a user's feed, newest first. Posts of users the user no
+// longer follows or has muted, and posts of muted conversations, are skipped.
+func (s *FeedService) GetFeed(ctx context.Context, userID uuid.UUID, page pagination.Request) (model.FeedPage, error) {
+	if userID == uuid.Nil {
+		return model.FeedPage{}, ErrMissingUser
+	}
+	if page.Backward() {
+		return model.FeedPage{}, ErrBackwardNotSupported
+	}
+
+	mutes, err := s.followersRepository.GetMutes(ctx, userID)
+	if err != nil {
+		return model.FeedPage{}, fmt.Errorf("failed to get mutes for user with ID %s: %w", userID, err)
+	}
+	mutedUsers := make(map[uuid.UUID]bool, len(mutes.Users))
+	for _, mute := range mutes.Users {
+		mutedUsers[mute.TargetID] = true
+	}
+	mutedConversations := make(map[uuid.UUID]bool, len(mutes.Conversations))
+	for _, mute := range mutes.Conversations {
+		mutedConversations[mute.TargetID] = true
+	}
+
+	outboxAuthors, err := s.followedOutboxAuthors(ctx, userID)
+	if err != nil {
+		return model.FeedPage{}, err
+	}
+
+	feed := model.FeedPage{Posts: []model.Post{}}
+	after := page.After
+	for scan := 0; scan < maxFeedScans && len(feed.Posts) < page.Limit; scan++ {
+		posts, hasMore, err := s.feedRepository.FindFeedPage(ctx, userID, outboxAuthors, after, page.Limit-len(feed.Posts))
+		if err != nil {
+			return model.FeedPage{}, fmt.Errorf("failed to get feed for user with ID %s: %w", userID, err)
+		}
+
+		visible, err := s.filterPosts(ctx, userID, posts, mutedUsers, mutedConversations)
+		if err != nil {
+			return model.FeedPage{}, err
+		}
+		feed.Posts = append(feed.Posts, visible...)
+
+		// Continue from the last post read, shown or skipped, so skipped posts are not read again
+		feed.NextCursor = ""
+		if !hasMore || len(posts) == 0 {
+			break
+		}
+		last := posts[len(posts)-1]
+		after = &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		feed.NextCursor = after.Encode()
+	}
+
+	return feed, nil
+}
+
+// followedOutboxAuthors retrieves the outbox authors a user follows, whose posts are merged into the feed.
+// It walks the users the user follows, which stay few for most users while outbox authors keep growing,
+// and looks each page of them up among the outbox authors.
+func (s *FeedService) followedOutboxAuthors(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var followedAuthors []uuid.UUID
+	err := s.followersRepository.ForEachFollowingPage(ctx, userID, func(followeeIDs []uuid.UUID) error {
+		authors, err := s.feedRepository.FindOutboxAuthors(ctx, followeeIDs)
+		if err != nil {
+			return fmt.Errorf("failed to get outbox authors: %w", err)
+		}
+		followedAuthors = append(followedAuthors, authors...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users followed by user with ID %s: %w", userID, err)
+	}
+	return followedAuthors, nil
+}
+
+// filterPosts drops the posts a user has muted, the timeline posts of authors the user
+// unfollowed since they were fanned out, and the tombstones of posts deleted during their fan-out
+func (s *FeedService) filterPosts(ctx context.Context, userID uuid.UUID, posts []model.Post, mutedUsers, mutedConversations map[uuid.UUID]bool) ([]model.Post, error) {
+	var authors []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for _, post := range posts {
+		if post.FannedOut && !seen[post.AuthorID] {
+			seen[post.AuthorID] = true
+			authors = append(authors, post.AuthorID)
+		}
+	}
+
+	followed, err := s.followersRepository.GetFollowedUsers(ctx, userID, authors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationships for user with ID %s: %w", userID, err)
+	}
+
+	visible := make([]model.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.Deleted || post.FannedOut && !followed[post.AuthorID] {
+			continue
+		}
+		if mutedUsers[post.AuthorID] || mutedConversations[post.ID] {
+			continue
+		}
+		// A share belongs to the conversation of the post it shares
+		if post.OriginalPostID != nil && mutedConversations[*post.OriginalPostID] {
+			continue
+		}
+		visible = append(visible, post)
+	}
+	return visible, nil
+}
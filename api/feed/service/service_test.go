@@ -0,0 +1,284 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"hornet/api/feed/model"
+	"hornet/api/feed/repository"
+	"hornet/api/feed/service"
+	followersmodel "hornet/api/followers/model"
+	"hornet/common/pagination"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// feed runs a FeedService over in-memory feeds and social graph
+type feed struct {
+	t         *testing.T
+	service   *service.FeedService
+	followers *repository.MemoryFollowersRepository
+}
+
+// newFeed creates a feed whose authors with more than fanOutThreshold followers are read from their outbox
+func newFeed(t *testing.T, fanOutThreshold int) *feed {
+	followers := repository.NewMemoryFollowersRepository()
+	feedService := service.NewFeedService(repository.NewMemoryFeedRepository(), followers, service.Options{
+		FanOutThreshold: fanOutThreshold,
+	})
+	return &feed{t: t, service: feedService, followers: followers}
+}
+
+func (f *feed) publish(post model.Post) {
+	f.t.Helper()
+	if err := f.service.PublishPost(context.Background(), post); err != nil {
+		f.t.Fatalf("PublishPost(%s, version %d): %v", post.ID, post.Version, err)
+	}
+}
+
+func (f *feed) retract(postID uuid.UUID, version int) {
+	f.t.Helper()
+	if err := f.service.RetractPost(context.Background(), postID, version); err != nil {
+		f.t.Fatalf("RetractPost(%s, version %d): %v", postID, version, err)
+	}
+}
+
+// read returns the whole feed of a user, reading pages of limit posts
+func (f *feed) read(userID uuid.UUID, limit int) []model.Post {
+	f.t.Helper()
+	var posts []model.Post
+	page := pagination.Request{Limit: limit}
+	for {
+		feedPage, err := f.service.GetFeed(context.Background(), userID, page)
+		if err != nil {
+			f.t.Fatalf("GetFeed: %v", err)
+		}
+		posts = append(posts, feedPage.Posts...)
+		if feedPage.NextCursor == "" {
+			return posts
+		}
+
+		cursor, err := pagination.DecodeCursor(feedPage.NextCursor)
+		if err != nil {
+			f.t.Fatalf("DecodeCursor: %v", err)
+		}
+		page.After = &cursor
+	}
+}
+
+// newPost returns a post of the author created at the given minute
+func newPost(authorID uuid.UUID, minute int) model.Post {
+	return model.Post{
+		ID:        uuid.New(),
+		Content:   "content",
+		AuthorID:  authorID,
+		CreatedAt: time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC),
+	}
+}
+
+// checkFeed fails the test unless the feed holds exactly the given posts, in order, with their content
+func checkFeed(t *testing.T, got []model.Post, want ...model.Post) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("feed: got %d posts, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Content != want[i].Content {
+			t.Errorf("post %d: got %s %q, want %s %q", i, got[i].ID, got[i].Content, want[i].ID, want[i].Content)
+		}
+	}
+}
+
+func TestPublishPost(t *testing.T) {
+	f := newFeed(t, 10)
+	author, follower, stranger := uuid.New(), uuid.New(), uuid.New()
+	f.followers.Follow(follower, author)
+
+	post := newPost(author, 0)
+	f.publish(post)
+
+	reply := newPost(author, 1)
+	reply.ParentPostID = &post.ID
+	f.publish(reply)
+
+	checkFeed(t, f.read(follower, 10), post)
+	checkFeed(t, f.read(stranger, 10))
+
+	// An edit refreshes the snapshot, and the edit it replaced arriving late is ignored
+	edited := post
+	edited.Content, edited.Version = "edited", 2
+	f.publish(edited)
+	stale := post
+	stale.Content, stale.Version = "stale", 1
+	f.publish(stale)
+	f.publish(post)
+
+	checkFeed(t, f.read(follower, 10), edited)
+}
+
+// interruptedFollowers cuts the next fan-outs short, after half the followers of the first page
+type interruptedFollowers struct {
+	*repository.MemoryFollowersRepository
+	interruptions int
+}
+
+var errInterrupted = errors.New("fan-out interrupted")
+
+func (f *interruptedFollowers) ForEachFollowersPage(ctx context.Context, userID uuid.UUID, fn func(followerIDs []uuid.UUID) error) error {
+	if f.interruptions == 0 {
+		return f.MemoryFollowersRepository.ForEachFollowersPage(ctx, userID, fn)
+	}
+	f.interruptions--
+	return f.MemoryFollowersRepository.ForEachFollowersPage(ctx, userID, func(followerIDs []uuid.UUID) error {
+		if err := fn(followerIDs[:len(followerIDs)/2]); err != nil {
+			return err
+		}
+		return errInterrupted
+	})
+}
+
+func TestPublishPostResumesFanOut(t *testing.T) {
+	ctx := context.Background()
+	followers := &interruptedFollowers{MemoryFollowersRepository: repository.NewMemoryFollowersRepository(), interruptions: 1}
+	f := &feed{t: t, followers: followers.MemoryFollowersRepository}
+	f.service = service.NewFeedService(repository.NewMemoryFeedRepository(), followers, service.Options{FanOutThreshold: 10})
+
+	author := uuid.New()
+	readers := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	for _, reader := range readers {
+		f.followers.Follow(reader, author)
+	}
+
+	post := newPost(author, 0)
+	if err := f.service.PublishPost(ctx, post); !errors.Is(err, errInterrupted) {
+		t.Fatalf("PublishPost: got %v, want %v", err, errInterrupted)
+	}
+	reached := 0
+	for _, reader := range readers {
+		reached += len(f.read(reader, 10))
+	}
+	if reached != len(readers)/2 {
+		t.Fatalf("interrupted fan-out: reached %d followers, want %d", reached, len(readers)/2)
+	}
+
+	// Sending the same version again completes the fan-out, and once it completed it is not run again
+	f.publish(post)
+	for _, reader := range readers {
+		checkFeed(t, f.read(reader, 10), post)
+	}
+	followers.interruptions = 1
+	f.publish(post)
+}
+
+func TestRetractPost(t *testing.T) {
+	cases := []struct {
+		name    string
+		updates func(f *feed, post model.Post)
+		visible bool
+	}{
+		{
+			name: "retract",
+			updates: func(f *feed, post model.Post) {
+				f.publish(post)
+				f.retract(post.ID, 1)
+			},
+		},
+		{
+			name: "publish arriving after the retract",
+			updates: func(f *feed, post model.Post) {
+				f.retract(post.ID, 1)
+				f.publish(post)
+			},
+		},
+		{
+			// An edit racing the delete takes the version the delete expected
+			name: "edit of the same version as the retract",
+			updates: func(f *feed, post model.Post) {
+				f.publish(post)
+				f.retract(post.ID, 1)
+				post.Content, post.Version = "edited", 1
+				f.publish(post)
+			},
+		},
+		{
+			name: "stale retract after a restore",
+			updates: func(f *feed, post model.Post) {
+				f.publish(post)
+				post.Version = 2
+				f.publish(post)
+				f.retract(post.ID, 1)
+			},
+			visible: true,
+		},
+		{
+			name: "restore",
+			updates: func(f *feed, post model.Post) {
+				f.publish(post)
+				f.retract(post.ID, 1)
+				post.Version = 2
+				f.publish(post)
+			},
+			visible: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFeed(t, 10)
+			author, follower := uuid.New(), uuid.New()
+			f.followers.Follow(follower, author)
+
+			post := newPost(author, 0)
+			tc.updates(f, post)
+
+			got := f.read(follower, 10)
+			if tc.visible {
+				checkFeed(t, got, post)
+			} else {
+				checkFeed(t, got)
+			}
+		})
+	}
+}
+
+func TestGetFeedMergesOutboxes(t *testing.T) {
+	f := newFeed(t, 1)
+	reader, other := uuid.New(), uuid.New()
+	popular, small, unfollowed, muted := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	// popular and unfollowed have too many followers to be fanned out
+	f.followers.Follow(reader, popular)
+	f.followers.Follow(other, popular)
+	f.followers.Follow(reader, small)
+	f.followers.Follow(reader, muted)
+	f.followers.Follow(uuid.New(), unfollowed)
+	f.followers.Follow(other, unfollowed)
+	f.followers.SetMutes(reader, followersmodel.Mutes{Users: []followersmodel.Mute{{Kind: "user", TargetID: muted}}})
+
+	popularOld, popularNew := newPost(popular, 0), newPost(popular, 3)
+	smallPost, unfollowedPost, mutedPost := newPost(small, 1), newPost(unfollowed, 2), newPost(muted, 4)
+	deleted := newPost(popular, 5)
+	for _, post := range []model.Post{popularOld, popularNew, smallPost, unfollowedPost, mutedPost, deleted} {
+		f.publish(post)
+	}
+	f.retract(deleted.ID, 1)
+
+	for _, limit := range []int{1, 2, 10} {
+		checkFeed(t, f.read(reader, limit), popularNew, smallPost, popularOld)
+	}
+
+	// Unfollowing drops the timeline posts and the outbox alike
+	f.followers.Unfollow(reader, popular)
+	f.followers.Unfollow(reader, small)
+	checkFeed(t, f.read(reader, 10))
+}
+
+func TestGetFeedWithoutUser(t *testing.T) {
+	f := newFeed(t, 10)
+	_, err := f.service.GetFeed(context.Background(), uuid.Nil, pagination.Request{Limit: 10})
+	if !errors.Is(err, service.ErrMissingUser) {
+		t.Fatalf("GetFeed: got %v, want ErrMissingUser", err)
+	}
+}
@@ -0,0 +1,247 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"hornet/api/followers/model"
+	"hornet/common/httpclient"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client calls the followers service. Requests are sent on behalf of the user set on the
+// context with httpclient.WithUserID, or whose token is set with httpclient.WithBearerToken
+// when the service verifies tokens. Error statuses are returned as *httpclient.StatusError.
+type Client struct {
+	http *httpclient.Client
+}
+
+// New creates a Client for the followers service at baseURL. A nil httpClient uses httpclient.DefaultTimeout.
+func New(baseURL string, httpClient *http.Client) *Client {
+	return &Client{http: httpclient.New(baseURL, httpClient)}
+}
+
+// CreateFollow follows a user on behalf of the requesting user. Following a private
+// account returns a pending follow request in Request instead of a Follow.
+func (c *Client) CreateFollow(ctx context.Context, receiverID uuid.UUID) (model.FollowResult, error) {
+	req := model.CreateFollow{ReceiverID: receiverID.String()}
+
+	// Follows and follow requests share their fields, the status tells them apart
+	var follow model.Follow
+	status, err := c.http.Do(ctx, http.MethodPost, "/followers", nil, req, &follow)
+	if err != nil {
+		return model.FollowResult{}, err
+	}
+	if status == http.StatusAccepted {
+		request := model.FollowRequest(follow)
+		return model.FollowResult{Request: &request}, nil
+	}
+	return model.FollowResult{Follow: &follow}, nil
+}
+
+// DeleteFollow deletes a follow the requesting user sent or received.
+func (c *Client) DeleteFollow(ctx context.Context, followID uuid.UUID) error {
+	_, err := c.http.Do(ctx, http.MethodDelete, "/followers/"+followID.String(), nil, nil, nil)
+	return err
+}
+
+// Unfollow stops the requesting user from following a user.
+func (c *Client) Unfollow(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.http.Do(ctx, http.MethodDelete, "/followers/user/"+userID.String(), nil, nil, nil)
+	return err
+}
+
+// Block blocks a user on behalf of the requesting user, severing the follows between them.
+func (c *Client) Block(ctx context.Context, userID uuid.UUID) (model.Block, error) {
+	var block model.Block
+	_, err := c.http.Do(ctx, http.MethodPost, "/followers/blocks/"+userID.String(), nil, nil, &block)
+	return block, err
+}
+
+// Unblock unblocks a user on behalf of the requesting user.
+func (c *Client) Unblock(ctx context.Context, userID uuid.UUID) error {
+	_, err := c.http.Do(ctx, http.MethodDelete, "/followers/blocks/"+userID.String(), nil, nil, nil)
+	return err
+}
+
+// IsBlocked reports whether blockerID blocks blockedID. The requesting user must be one of them,
+// unless the service token is set on the context with httpclient.WithServiceToken.
+func (c *Client) IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	query := url.Values{
+		"blocker_id": {blockerID.String()},
+		"blocked_id": {blockedID.String()},
+	}
+
+	var status model.BlockStatus
+	if _, err := c.http.Do(ctx, http.MethodGet, "/followers/blocks/check", query, nil, &status); err != nil {
+		return false, err
+	}
+	return status.Blocked, nil
+}
+
+// GetMutes retrieves the active mutes of the requesting user.
+func (c *Client) GetMutes(ctx context.Context) (model.Mutes, error) {
+	var mutes model.Mutes
+	_, err := c.http.Do(ctx, http.MethodGet, "/followers/mutes", nil, nil, &mutes)
+	return mutes, err
+}
+
+// Mute mutes a user or a conversation, by kind, on behalf of the requesting user,
+// until expiresAt unless it is nil.
+func (c *Client) Mute(ctx context.Context, kind string, targetID uuid.UUID, expiresAt *time.Time) (model.Mute, error) {
+	path, err := mutePath(kind, targetID)
+	if err != nil {
+		return model.Mute{}, err
+	}
+
+	var mute model.Mute
+	_, err = c.http.Do(ctx, http.MethodPut, path, nil, model.CreateMute{ExpiresAt: expiresAt}, &mute)
+	return mute, err
+}
+
+// Unmute lifts a mute of a user or a conversation, by kind, on behalf of the requesting user.
+func (c *Client) Unmute(ctx context.Context, kind string, targetID uuid.UUID) error {
+	path, err := mutePath(kind, targetID)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.http.Do(ctx, http.MethodDelete, path, nil, nil, nil)
+	return err
+}
+
+// SetPrivate sets whether following the requesting user requires their approval.
+func (c *Client) SetPrivate(ctx context.Context, private bool) error {
+	_, err := c.http.Do(ctx, http.MethodPut, "/followers/privacy", nil, model.Privacy{Private: &private}, nil)
+	return err
+}
+
+// GetFollowRequests retrieves one page of the pending follow requests sent to the requesting
+// user, or sent by them, by direction.
+func (c *Client) GetFollowRequests(ctx context.Context, direction string, page httpclient.Page) (model.FollowRequestsPage, error) {
+	query := page.Values()
+	if direction != "" {
+		query.Set("direction", direction)
+	}
+
+	var requests model.FollowRequestsPage
+	_, err := c.http.Do(ctx, http.MethodGet, "/followers/requests", query, nil, &requests)
+	return requests, err
+}
+
+// ApproveFollowRequest approves a follow request sent to the requesting user.
+func (c *Client) ApproveFollowRequest(ctx context.Context, requestID uuid.UUID) (model.Follow, error) {
+	var follow model.Follow
+	_, err := c.http.Do(ctx, http.MethodPost, "/followers/requests/"+requestID.String()+"/approve", nil, nil, &follow)
+	return follow, err
+}
+
+// RejectFollowRequest rejects a follow request sent to the requesting user.
+func (c *Client) RejectFollowRequest(ctx context.Context, requestID uuid.UUID) error {
+	_, err := c.http.Do(ctx, http.MethodPost, "/followers/requests/"+requestID.String()+"/reject", nil, nil, nil)
+	return err
+}
+
+// CancelFollowRequest cancels a follow request sent by the requesting user.
+func (c *Client) CancelFollowRequest(ctx context.Context, requestID uuid.UUID) error {
+	_, err := c.http.Do(ctx, http.MethodDelete, "/followers/requests/"+requestID.String(), nil, nil, nil)
+	return err
+}
+
+// GetRelationship retrieves the relationship between the requesting user and another user.
+func (c *Client) GetRelationship(ctx context.Context, otherID uuid.UUID) (model.Relationship, error) {
+	var relationship model.Relationship
+	_, err := c.http.Do(ctx, http.MethodGet, "/followers/relationship/"+otherID.String(), nil, nil, &relationship)
+	return relationship, err
+}
+
+// GetRelationships retrieves the relationships between the requesting user and each of the
+// other users, at most 100 of them, in the order of otherIDs.
+func (c *Client) GetRelationships(ctx context.Context, otherIDs []uuid.UUID) ([]model.Relationship, error) {
+	ids := make([]string, 0, len(otherIDs))
+	for _, id := range otherIDs {
+		ids = append(ids, id.String())
+	}
+	query := url.Values{"user_ids": {strings.Join(ids, ",")}}
+
+	var relationships []model.Relationship
+	_, err := c.http.Do(ctx, http.MethodGet, "/followers/relationships", query, nil, &relationships)
+	return relationships, err
+}
+
+// GetSuggestions retrieves one page of users the requesting user may want to follow.
+func (c *Client) GetSuggestions(ctx context.Context, page httpclient.Page) (model.SuggestionsPage, error) {
+	var suggestions model.SuggestionsPage
+	_, err := c.http.Do(ctx, http.MethodGet, "/followers/suggestions", page.Values(), nil, &suggestions)
+	return suggestions, err
+}
+
+// GetFollowers retrieves one page of the follows a user received, newest first.
+func (c *Client) GetFollowers(ctx context.Context, userID uuid.UUID, page httpclient.Page) (model.FollowsPage, error) {
+	var follows model.FollowsPage
+	_, err := c.http.Do(ctx, http.MethodGet, userPath(userID, "followers"), page.Values(), nil, &follows)
+	return follows, err
+}
+
+// GetFollowing retrieves one page of the follows a user sent, newest first.
+func (c *Client) GetFollowing(ctx context.Context, userID uuid.UUID, page httpclient.Page) (model.FollowsPage, error) {
+	var follows model.FollowsPage
+	_, err := c.http.Do(ctx, http.MethodGet, userPath(userID, "following"), page.Values(), nil, &follows)
+	return follows, err
+}
+
+// GetMutuals retrieves one page of the users who follow a user and are followed back.
+func (c *Client) GetMutuals(ctx context.Context, userID uuid.UUID, page httpclient.Page) (model.UsersPage, error) {
+	var users model.UsersPage
+	_, err := c.http.Do(ctx, http.MethodGet, userPath(userID, "mutuals"), page.Values(), nil, &users)
+	return users, err
+}
+
+// GetKnownFollowers retrieves one page of a user's followers that the requesting user follows.
+func (c *Client) GetKnownFollowers(ctx context.Context, userID uuid.UUID, page httpclient.Page) (model.UsersPage, error) {
+	var users model.UsersPage
+	_, err := c.http.Do(ctx, http.MethodGet, userPath(userID, "known-followers"), page.Values(), nil, &users)
+	return users, err
+}
+
+// GetFollowersCount retrieves the number of followers of a user.
+func (c *Client) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	return c.getCount(ctx, userPath(userID, "followers/count"))
+}
+
+// GetFollowingCount retrieves the number of users a user follows.
+func (c *Client) GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	return c.getCount(ctx, userPath(userID, "following/count"))
+}
+
+// getCount retrieves a {"count": ...} response
+func (c *Client) getCount(ctx context.Context, path string) (int, error) {
+	var body struct {
+		Count int `json:"count"`
+	}
+	if _, err := c.http.Do(ctx, http.MethodGet, path, nil, nil, &body); err != nil {
+		return 0, err
+	}
+	return body.Count, nil
+}
+
+// userPath returns the path of a list about a user
+func userPath(userID uuid.UUID, list string) string {
+	return "/followers/user/" + userID.String() + "/" + list
+}
+
+// mutePath returns the path of a mute of the given kind
+func mutePath(kind string, targetID uuid.UUID) (string, error) {
+	switch kind {
+	case model.MuteKindUser:
+		return "/followers/mutes/users/" + targetID.String(), nil
+	case model.MuteKindConversation:
+		return "/followers/mutes/conversations/" + targetID.String(), nil
+	default:
+		return "", fmt.Errorf("unknown mute kind %q", kind)
+	}
+}
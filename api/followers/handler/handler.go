@@ -3,86 +3,401 @@ package handler
 import (
 	"hornet/api/followers/model"
 	"hornet/api/followers/service"
+	"hornet/common/auth"
 	"hornet/common/logger"
+	"hornet/common/pagination"
+	"hornet/common/problem"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+var (
+	errInvalidUserID     = problem.BadRequest("invalid_user_id", "Invalid UserID")
+	errMissingUserIDs    = problem.BadRequest("missing_user_ids", "user_ids query parameter is required")
+	errInvalidReceiverID = problem.BadRequest("invalid_receiver_id", "Invalid ReceiverID")
+	errInvalidBlockerID  = problem.BadRequest("invalid_blocker_id", "Invalid blocker_id")
+	errInvalidBlockedID  = problem.BadRequest("invalid_blocked_id", "Invalid blocked_id")
+	errInvalidRequestID  = problem.BadRequest("invalid_request_id", "Invalid RequestID")
+	errInvalidFollowID   = problem.BadRequest("invalid_follow_id", "Invalid FollowID")
+	errInvalidBody       = problem.BadRequest("invalid_body", "Invalid request body")
+)
+
 // CreateFollow creates a new follow relationship.
-func CreateFollow(service *service.FollowersService) gin.HandlerFunc {
+func CreateFollow(followersService *service.FollowersService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
 
-		userIDStr := c.GetHeader("X-User-ID")
-		if userIDStr == "" {
-			logger.WithContext(c).Warn("Missing X-User-ID header")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "X-User-ID header is required"})
+		var req model.CreateFollow
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Respond(c, errInvalidBody)
 			return
 		}
 
-		userID, err := uuid.Parse(userIDStr)
+		receiverID, err := uuid.Parse(req.ReceiverID)
 		if err != nil {
-			logger.WithContext(c).Error("Invalid userID ", userIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UserID"})
+			problem.Respond(c, errInvalidReceiverID)
 			return
 		}
 
-		var req model.CreateFollow
-		if err := c.ShouldBindJSON(&req); err != nil {
-			logger.WithContext(c).Error("Invalid request body ", " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		result, created, err := followersService.CreateFollow(c.Request.Context(), userID, receiverID)
+		if err != nil {
+			problem.Respond(c, err)
 			return
 		}
 
-		receiverID, err := uuid.Parse(req.ReceiverID)
+		// Private accounts get a request to approve instead of a follow, answered with 202
+		// whether it is new or not, so clients can tell requests from follows
+		if request := result.Request; request != nil {
+			if !created {
+				logger.WithContext(c).Info("Follow request already exists ", request.ID)
+			} else {
+				logger.WithContext(c).Info("Follow request created successfully ", request.ID)
+			}
+			c.JSON(http.StatusAccepted, request)
+			return
+		}
+
+		follow := result.Follow
+		if !created {
+			logger.WithContext(c).Info("Follow already exists ", follow.ID)
+			c.JSON(http.StatusOK, follow)
+			return
+		}
+
+		logger.WithContext(c).Info("Follow created successfully ", follow.ID)
+		c.JSON(http.StatusCreated, follow)
+	}
+}
+
+// Block blocks a user on behalf of the requesting user.
+func Block(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		blockedIDStr := c.Param("user_id")
+		blockedID, err := uuid.Parse(blockedIDStr)
 		if err != nil {
-			logger.WithContext(c).Error("Invalid ReceiverID ", req.ReceiverID, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ReceiverID"})
+			problem.Respond(c, errInvalidUserID)
 			return
 		}
 
-		follow, err := service.CreateFollow(c.Request.Context(), userID, receiverID)
+		block, created, err := followersService.Block(c.Request.Context(), userID, blockedID)
 		if err != nil {
-			logger.WithContext(c).Error("Error creating follow ", "error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
-		logger.WithContext(c).Info("Follow created successfully ", follow.ID)
+		if !created {
+			logger.WithContext(c).Info("Block already exists ", userID, " -> ", blockedID)
+			c.JSON(http.StatusOK, block)
+			return
+		}
+
+		logger.WithContext(c).Info("User blocked successfully ", userID, " -> ", blockedID)
+		c.JSON(http.StatusCreated, block)
+	}
+}
+
+// Unblock removes a block created by the requesting user.
+func Unblock(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		blockedIDStr := c.Param("user_id")
+		blockedID, err := uuid.Parse(blockedIDStr)
+		if err != nil {
+			problem.Respond(c, errInvalidUserID)
+			return
+		}
+
+		err = followersService.Unblock(c.Request.Context(), userID, blockedID)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("User unblocked successfully ", userID, " -> ", blockedID)
+		c.JSON(http.StatusOK, gin.H{"message": "User unblocked successfully"})
+	}
+}
+
+// CheckBlock reports whether a user blocks another, passed as the blocker_id and blocked_id
+// query parameters. Other services may check any pair with the service token, while users
+// may only check the blocks they are part of, so block lists stay private.
+func CheckBlock(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		blockerIDStr := c.Query("blocker_id")
+		blockerID, err := uuid.Parse(blockerIDStr)
+		if err != nil {
+			problem.Respond(c, errInvalidBlockerID)
+			return
+		}
+
+		blockedIDStr := c.Query("blocked_id")
+		blockedID, err := uuid.Parse(blockedIDStr)
+		if err != nil {
+			problem.Respond(c, errInvalidBlockedID)
+			return
+		}
+
+		if !auth.FromService(c) {
+			userID, ok := requireUserID(c)
+			if !ok {
+				return
+			}
+			if userID != blockerID && userID != blockedID {
+				problem.Respond(c, service.ErrForbidden)
+				return
+			}
+		}
+
+		status, err := followersService.IsBlocked(c.Request.Context(), blockerID, blockedID)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+// Mute mutes a user or a conversation on behalf of the requesting user. The target is read
+// from the param path parameter, and the body may set when the mute expires.
+func Mute(followersService *service.FollowersService, kind, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		targetIDStr := c.Param(param)
+		targetID, err := uuid.Parse(targetIDStr)
+		if err != nil {
+			problem.Respond(c, problem.BadRequest("invalid_"+param, "Invalid "+param))
+			return
+		}
+
+		var req model.CreateMute
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				problem.Respond(c, errInvalidBody)
+				return
+			}
+		}
+
+		mute, err := followersService.Mute(c.Request.Context(), userID, kind, targetID, req.ExpiresAt)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Muted ", kind, " successfully ", targetID)
+		c.JSON(http.StatusOK, mute)
+	}
+}
+
+// Unmute removes a mute of a user or a conversation, read from the param path parameter.
+func Unmute(followersService *service.FollowersService, kind, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		targetIDStr := c.Param(param)
+		targetID, err := uuid.Parse(targetIDStr)
+		if err != nil {
+			problem.Respond(c, problem.BadRequest("invalid_"+param, "Invalid "+param))
+			return
+		}
+
+		err = followersService.Unmute(c.Request.Context(), userID, kind, targetID)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Unmuted ", kind, " successfully ", targetID)
+		c.JSON(http.StatusOK, gin.H{"message": "Unmuted successfully"})
+	}
+}
+
+// GetMutes gets the active mutes of the requesting user, for filtering their feed.
+func GetMutes(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		mutes, err := followersService.GetMutes(c.Request.Context(), userID)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Mutes fetched successfully for user ", userID)
+		c.JSON(http.StatusOK, mutes)
+	}
+}
+
+// SetPrivacy sets whether following the user requires their approval.
+func SetPrivacy(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		var req model.Privacy
+		if err := c.ShouldBindJSON(&req); err != nil || req.Private == nil {
+			problem.Respond(c, errInvalidBody)
+			return
+		}
+
+		err := followersService.SetPrivate(c.Request.Context(), userID, *req.Private)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Privacy set successfully for user ", userID)
+		c.JSON(http.StatusOK, req)
+	}
+}
+
+// GetFollowRequests gets a page of the follow requests sent to the user, or sent by them
+// with direction=outgoing.
+func GetFollowRequests(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.ParseRequest(c)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		direction := c.DefaultQuery("direction", model.RequestsIncoming)
+		requests, err := followersService.GetFollowRequests(c.Request.Context(), userID, direction, page)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Follow requests fetched successfully for user ", userID)
+		c.JSON(http.StatusOK, requests)
+	}
+}
+
+// ApproveFollowRequest approves a follow request sent to the user.
+func ApproveFollowRequest(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, requestID, ok := parseFollowRequest(c)
+		if !ok {
+			return
+		}
+
+		follow, err := followersService.ApproveFollowRequest(c.Request.Context(), userID, requestID)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Follow request approved successfully ", requestID)
 		c.JSON(http.StatusCreated, follow)
 	}
 }
 
-// DeleteFollow deletes an existing follow relationship.
-func DeleteFollow(service *service.FollowersService) gin.HandlerFunc {
+// RejectFollowRequest rejects a follow request sent to the user.
+func RejectFollowRequest(followersService *service.FollowersService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		userID, requestID, ok := parseFollowRequest(c)
+		if !ok {
+			return
+		}
 
-		userIDStr := c.GetHeader("X-User-ID")
-		if userIDStr == "" {
-			logger.WithContext(c).Warn("Missing X-User-ID header")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "X-User-ID header is required"})
+		err := followersService.RejectFollowRequest(c.Request.Context(), userID, requestID)
+		if err != nil {
+			problem.Respond(c, err)
 			return
 		}
 
-		userID, err := uuid.Parse(userIDStr)
+		logger.WithContext(c).Info("Follow request rejected successfully ", requestID)
+		c.JSON(http.StatusOK, gin.H{"message": "Follow request rejected successfully"})
+	}
+}
+
+// CancelFollowRequest cancels a follow request sent by the user.
+func CancelFollowRequest(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, requestID, ok := parseFollowRequest(c)
+		if !ok {
+			return
+		}
+
+		err := followersService.CancelFollowRequest(c.Request.Context(), userID, requestID)
 		if err != nil {
-			logger.WithContext(c).Error("Invalid userID ", userIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UserID"})
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Follow request cancelled successfully ", requestID)
+		c.JSON(http.StatusOK, gin.H{"message": "Follow request cancelled successfully"})
+	}
+}
+
+// parseFollowRequest reads the requesting user and the request_id path parameter,
+// writing a 400 response and returning false when either is invalid.
+func parseFollowRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	requestIDStr := c.Param("request_id")
+	requestID, err := uuid.Parse(requestIDStr)
+	if err != nil {
+		problem.Respond(c, errInvalidRequestID)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, requestID, true
+}
+
+// DeleteFollow deletes an existing follow relationship.
+func DeleteFollow(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
 			return
 		}
 
 		followIDStr := c.Param("follow_id")
 		followID, err := uuid.Parse(followIDStr)
 		if err != nil {
-			logger.WithContext(c).Error("Invalid followID ", followIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid FollowID"})
+			problem.Respond(c, errInvalidFollowID)
 			return
 		}
 
-		if err := service.DeleteFollow(c.Request.Context(), userID, followID); err != nil {
-			logger.WithContext(c).Error("Error deleting follow ", "error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		err = followersService.DeleteFollow(c.Request.Context(), userID, followID)
+		if err != nil {
+			problem.Respond(c, err)
 			return
 		}
 
@@ -91,22 +406,203 @@ func DeleteFollow(service *service.FollowersService) gin.HandlerFunc {
 	}
 }
 
+// Unfollow deletes the follow relationship from the user to another user.
+func Unfollow(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		targetIDStr := c.Param("user_id")
+		targetID, err := uuid.Parse(targetIDStr)
+		if err != nil {
+			problem.Respond(c, errInvalidUserID)
+			return
+		}
+
+		err = followersService.Unfollow(c.Request.Context(), userID, targetID)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("User unfollowed successfully ", targetID)
+		c.JSON(http.StatusOK, gin.H{"message": "Follow deleted successfully"})
+	}
+}
+
+// GetRelationship gets whether the user and another user follow each other.
+func GetRelationship(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		otherIDStr := c.Param("other_id")
+		otherID, err := uuid.Parse(otherIDStr)
+		if err != nil {
+			problem.Respond(c, errInvalidUserID)
+			return
+		}
+
+		relationship, err := followersService.GetRelationship(c.Request.Context(), userID, otherID)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Relationship fetched successfully for user ", otherID)
+		c.JSON(http.StatusOK, relationship)
+	}
+}
+
+// GetRelationships gets whether the user and each user of a comma-separated user_ids list follow each other.
+func GetRelationships(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		var otherIDs []uuid.UUID
+		for _, idStr := range strings.Split(c.Query("user_ids"), ",") {
+			if idStr = strings.TrimSpace(idStr); idStr == "" {
+				continue
+			}
+			otherID, err := uuid.Parse(idStr)
+			if err != nil {
+				problem.Respond(c, errInvalidUserID)
+				return
+			}
+			otherIDs = append(otherIDs, otherID)
+		}
+
+		if len(otherIDs) == 0 {
+			problem.Respond(c, errMissingUserIDs)
+			return
+		}
+
+		relationships, err := followersService.GetRelationships(c.Request.Context(), userID, otherIDs)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Relationships fetched successfully for user ", userID)
+		c.JSON(http.StatusOK, relationships)
+	}
+}
+
+// GetSuggestions gets a page of users the user may want to follow.
+func GetSuggestions(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.ParseRequest(c)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		suggestions, err := followersService.GetSuggestions(c.Request.Context(), userID, page)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		// Suggestions change slowly and are specific to the user, let the client cache them
+		c.Header("Cache-Control", "private, max-age=300")
+
+		logger.WithContext(c).Info("Suggestions fetched successfully for user ", userID)
+		c.JSON(http.StatusOK, suggestions)
+	}
+}
+
+// GetMutuals gets a page of the users who follow a user and are followed back by them.
+func GetMutuals(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		userIDStr := c.Param("user_id")
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			problem.Respond(c, errInvalidUserID)
+			return
+		}
+
+		page, err := pagination.ParseRequest(c)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		mutuals, err := followersService.GetMutuals(c.Request.Context(), userID, page)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Mutuals fetched successfully for user ", userID)
+		c.JSON(http.StatusOK, mutuals)
+	}
+}
+
+// GetKnownFollowers gets a page of the followers of a user that the requesting user follows.
+func GetKnownFollowers(followersService *service.FollowersService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		viewerID, ok := requireUserID(c)
+		if !ok {
+			return
+		}
+
+		userIDStr := c.Param("user_id")
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			problem.Respond(c, errInvalidUserID)
+			return
+		}
+
+		page, err := pagination.ParseRequest(c)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		known, err := followersService.GetKnownFollowers(c.Request.Context(), viewerID, userID, page)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Known followers fetched successfully for user ", userID)
+		c.JSON(http.StatusOK, known)
+	}
+}
+
 // GetUserFollowers gets a list of followers for a user.
-func GetUserFollowers(service *service.FollowersService) gin.HandlerFunc {
+func GetUserFollowers(followersService *service.FollowersService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		userIDStr := c.Param("user_id")
 		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
-			logger.WithContext(c).Error("Invalid targetUserID ", userIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UserID"})
+			problem.Respond(c, errInvalidUserID)
+			return
+		}
+
+		page, err := pagination.ParseRequest(c)
+		if err != nil {
+			problem.Respond(c, err)
 			return
 		}
 
-		followers, err := service.GetFollowers(c.Request.Context(), userID)
+		followers, err := followersService.GetFollowers(c.Request.Context(), userID, page)
 		if err != nil {
-			logger.WithContext(c).Error("Error fetching followers ", "error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
@@ -116,20 +612,24 @@ func GetUserFollowers(service *service.FollowersService) gin.HandlerFunc {
 }
 
 // GetUserFollowing gets a list of users the given user is following.
-func GetUserFollowing(service *service.FollowersService) gin.HandlerFunc {
+func GetUserFollowing(followersService *service.FollowersService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr := c.Param("user_id")
 		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
-			logger.WithContext(c).Error("Invalid userID ", userIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UserID"})
+			problem.Respond(c, errInvalidUserID)
+			return
+		}
+
+		page, err := pagination.ParseRequest(c)
+		if err != nil {
+			problem.Respond(c, err)
 			return
 		}
 
-		following, err := service.GetFollowing(c.Request.Context(), userID)
+		following, err := followersService.GetFollowing(c.Request.Context(), userID, page)
 		if err != nil {
-			logger.WithContext(c).Error("Error fetching following ", "error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
@@ -139,20 +639,18 @@ func GetUserFollowing(service *service.FollowersService) gin.HandlerFunc {
 }
 
 // GetFollowersCount gets the count of followers for a user.
-func GetFollowersCount(service *service.FollowersService) gin.HandlerFunc {
+func GetFollowersCount(followersService *service.FollowersService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr := c.Param("user_id")
 		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
-			logger.WithContext(c).Error("Invalid userID ", userIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UserID"})
+			problem.Respond(c, errInvalidUserID)
 			return
 		}
 
-		count, err := service.GetFollowersCount(c.Request.Context(), userID)
+		count, err := followersService.GetFollowersCount(c.Request.Context(), userID)
 		if err != nil {
-			logger.WithContext(c).Error("Error fetching followers count ", "error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
@@ -162,20 +660,18 @@ func GetFollowersCount(service *service.FollowersService) gin.HandlerFunc {
 }
 
 // GetUserFollowingCount gets the count of users the given user is following.
-func GetFollowingCount(service *service.FollowersService) gin.HandlerFunc {
+func GetFollowingCount(followersService *service.FollowersService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDStr := c.Param("user_id")
 		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
-			logger.WithContext(c).Error("Invalid userID ", userIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UserID"})
+			problem.Respond(c, errInvalidUserID)
 			return
 		}
 
-		count, err := service.GetFollowingCount(c.Request.Context(), userID)
+		count, err := followersService.GetFollowingCount(c.Request.Context(), userID)
 		if err != nil {
-			logger.WithContext(c).Error("Error fetching following count ", "error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
@@ -183,3 +679,15 @@ func GetFollowingCount(service *service.FollowersService) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"count": count})
 	}
 }
+
+// requireUserID returns the ID of the authenticated user making the request.
+// It writes the error response and returns false when the request is unauthenticated.
+func requireUserID(c *gin.Context) (uuid.UUID, bool) {
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		problem.Respond(c, auth.ErrUnauthenticated)
+		return uuid.Nil, false
+	}
+
+	return principal.UserID, true
+}
@@ -15,7 +15,129 @@ type Follow struct {
 	CreatedAt  time.Time `json:"created_at"`  // When the relationship was created
 }
 
+// FollowRequest represents a pending :FOLLOW_REQUEST relationship from a user to a private account.
+// Once the receiver approves it, it is replaced by a Follow.
+type FollowRequest struct {
+	ID         uuid.UUID `json:"id"`          // The ID stored as a property on the relationship
+	SenderID   uuid.UUID `json:"sender_id"`   // The ID of the user node asking to follow
+	ReceiverID uuid.UUID `json:"receiver_id"` // The ID of the private user node asked to be followed
+	CreatedAt  time.Time `json:"created_at"`  // When the request was sent
+}
+
+// FollowRequestsPage represents one page of a cursor-paginated list of follow requests, newest first.
+type FollowRequestsPage struct {
+	Requests   []FollowRequest `json:"requests"`
+	NextCursor string          `json:"next_cursor,omitempty"` // Pass as `after` to fetch the following page
+	PrevCursor string          `json:"prev_cursor,omitempty"` // Pass as `before` to fetch the preceding page
+}
+
+// Directions of a list of follow requests, seen from the requesting user.
+const (
+	RequestsIncoming = "incoming" // Requests sent to the user
+	RequestsOutgoing = "outgoing" // Requests sent by the user
+)
+
+// FollowResult is the outcome of following a user: a follow for public accounts,
+// or a follow request for private ones. Exactly one of Follow and Request is set.
+type FollowResult struct {
+	Follow  *Follow
+	Request *FollowRequest
+}
+
+// Privacy represents the privacy settings of a user, and the request body for changing them.
+type Privacy struct {
+	Private *bool `json:"private"` // Whether following the user requires their approval
+}
+
+// FollowsPage represents one page of a cursor-paginated list of follows, newest first.
+type FollowsPage struct {
+	Follows    []Follow `json:"follows"`
+	NextCursor string   `json:"next_cursor,omitempty"` // Pass as `after` to fetch the following page
+	PrevCursor string   `json:"prev_cursor,omitempty"` // Pass as `before` to fetch the preceding page
+}
+
+// UsersPage represents one page of users related to a target user, ordered by ID.
+type UsersPage struct {
+	UserIDs    []uuid.UUID `json:"user_ids"`
+	Count      *int        `json:"count,omitempty"`       // Total number of users in the list, only on the first page
+	NextCursor string      `json:"next_cursor,omitempty"` // Pass as `after` to fetch the following page
+	PrevCursor string      `json:"prev_cursor,omitempty"` // Pass as `before` to fetch the preceding page
+}
+
 // CreateFollow represents the request body for creating a new follow.
 type CreateFollow struct {
 	ReceiverID string `json:"receiver_id"` // The ID of the user node that received the Follow
 }
+
+// Relationship describes whether the requesting user and another user follow each other.
+type Relationship struct {
+	UserID     uuid.UUID `json:"user_id"`     // The ID of the other user
+	Following  bool      `json:"following"`   // Whether the requesting user follows the other user
+	FollowedBy bool      `json:"followed_by"` // Whether the other user follows the requesting user
+	Requested  bool      `json:"requested"`   // Whether the requesting user has a pending request to follow the other user
+	Blocking   bool      `json:"blocking"`    // Whether the requesting user blocks the other user
+	BlockedBy  bool      `json:"blocked_by"`  // Whether the other user blocks the requesting user
+}
+
+// Block represents a :BLOCKS relationship in Neo4j. A block severs the follows and
+// follow requests between both users and prevents new ones in either direction.
+type Block struct {
+	BlockerID uuid.UUID `json:"blocker_id"` // The ID of the user node that created the block
+	BlockedID uuid.UUID `json:"blocked_id"` // The ID of the blocked user node
+	CreatedAt time.Time `json:"created_at"` // When the block was created
+}
+
+// BlockStatus reports whether a user blocks another user.
+type BlockStatus struct {
+	BlockerID uuid.UUID `json:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id"`
+	Blocked   bool      `json:"blocked"`
+}
+
+// Suggestion represents a user the requesting user may want to follow,
+// ranked by how many of the requesting user's followees follow them.
+type Suggestion struct {
+	UserID      uuid.UUID         `json:"user_id"`
+	MutualCount int               `json:"mutual_count"`     // Number of the requesting user's followees following this user
+	FollowedBy  []uuid.UUID       `json:"followed_by"`      // A sample of those followees
+	Reason      *SuggestionReason `json:"reason,omitempty"` // Why the user is suggested, for clients to render with user names
+}
+
+// SuggestionReason explains a suggestion with the followees to name and how many others
+// follow the suggested user, e.g. "Followed by X, Y and 2 others".
+type SuggestionReason struct {
+	FollowedBy  []uuid.UUID `json:"followed_by"`  // Followees to name, in the order of the sample
+	OthersCount int         `json:"others_count"` // Number of the other followees following this user
+}
+
+// SuggestionsPage represents one page of follow suggestions, best first.
+type SuggestionsPage struct {
+	Suggestions []Suggestion `json:"suggestions"`
+	NextCursor  string       `json:"next_cursor,omitempty"` // Pass as `after` to fetch the following page
+}
+
+// Kinds of mutes. A user mute hides a user's content, a conversation mute hides a thread by its root post.
+const (
+	MuteKindUser         = "user"
+	MuteKindConversation = "conversation"
+)
+
+// Mute represents a :MUTES relationship to a user or a :MUTES_CONVERSATION relationship
+// to a conversation. Unlike blocks, mutes are never revealed to the muted user.
+type Mute struct {
+	Kind      string     `json:"kind"`                 // Either MuteKindUser or MuteKindConversation
+	TargetID  uuid.UUID  `json:"target_id"`            // The muted user, or the root post of the muted conversation
+	CreatedAt time.Time  `json:"created_at"`           // When the mute was last set
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // When the mute lapses, never if unset
+}
+
+// Mutes represents the active mutes of a user, newest first.
+type Mutes struct {
+	Users         []Mute `json:"users"`
+	Conversations []Mute `json:"conversations"`
+}
+
+// CreateMute represents the optional request body for muting a user or a conversation.
+type CreateMute struct {
+	ExpiresAt *time.Time `json:"expires_at"` // When the mute lapses, never if unset
+}
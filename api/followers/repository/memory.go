@@ -0,0 +1,583 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hornet/api/followers/model"
+	"hornet/common/pagination"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// userPair identifies a directed relationship from one user to another.
+type userPair struct {
+	from uuid.UUID
+	to   uuid.UUID
+}
+
+// muteEntry identifies a mute of a target of some kind by a user.
+type muteEntry struct {
+	userID   uuid.UUID
+	kind     string
+	targetID uuid.UUID
+}
+
+// MemoryFollowersRepository stores the social graph in memory. It is safe for concurrent use.
+type MemoryFollowersRepository struct {
+	mu       sync.RWMutex
+	private  map[uuid.UUID]bool
+	follows  map[userPair]model.Follow
+	requests map[userPair]model.FollowRequest
+	blocks   map[userPair]model.Block
+	mutes    map[muteEntry]model.Mute
+}
+
+// NewMemoryFollowersRepository creates an empty MemoryFollowersRepository.
+func NewMemoryFollowersRepository() *MemoryFollowersRepository {
+	return &MemoryFollowersRepository{
+		private:  make(map[uuid.UUID]bool),
+		follows:  make(map[userPair]model.Follow),
+		requests: make(map[userPair]model.FollowRequest),
+		blocks:   make(map[userPair]model.Block),
+		mutes:    make(map[muteEntry]model.Mute),
+	}
+}
+
+// CreateFollow saves a follow relationship unless the sender already follows the receiver.
+// It returns the stored follow and whether this call created it, or ErrBlocked if either user blocks the other.
+func (r *MemoryFollowersRepository) CreateFollow(ctx context.Context, follow *model.Follow) (*model.Follow, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.blockedEitherWay(follow.SenderID, follow.ReceiverID) {
+		return nil, false, ErrBlocked
+	}
+
+	pair := userPair{from: follow.SenderID, to: follow.ReceiverID}
+	delete(r.requests, pair)
+
+	if existing, ok := r.follows[pair]; ok {
+		return &existing, false, nil
+	}
+
+	saved := *follow
+	r.follows[pair] = saved
+	return &saved, true, nil
+}
+
+// GetFollowByID retrieves a follow relationship by its ID.
+func (r *MemoryFollowersRepository) GetFollowByID(ctx context.Context, id uuid.UUID) (*model.Follow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, follow := range r.follows {
+		if follow.ID == id {
+			return &follow, nil
+		}
+	}
+	return nil, ErrFollowNotFound
+}
+
+// GetFollowBetween retrieves the follow relationship from a sender to a receiver.
+func (r *MemoryFollowersRepository) GetFollowBetween(ctx context.Context, senderID, receiverID uuid.UUID) (*model.Follow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	follow, ok := r.follows[userPair{from: senderID, to: receiverID}]
+	if !ok {
+		return nil, ErrFollowNotFound
+	}
+	return &follow, nil
+}
+
+// DeleteFollow deletes a follow relationship by its ID.
+func (r *MemoryFollowersRepository) DeleteFollow(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for pair, follow := range r.follows {
+		if follow.ID == id {
+			delete(r.follows, pair)
+		}
+	}
+	return nil
+}
+
+// DeleteFollowBetween deletes the follow relationship from a sender to a receiver.
+// It reports whether a relationship was deleted.
+func (r *MemoryFollowersRepository) DeleteFollowBetween(ctx context.Context, senderID, receiverID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pair := userPair{from: senderID, to: receiverID}
+	_, ok := r.follows[pair]
+	delete(r.follows, pair)
+	return ok, nil
+}
+
+// RepairDuplicateFollows has nothing to repair, follows are keyed by their users.
+func (r *MemoryFollowersRepository) RepairDuplicateFollows(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// SetPrivate sets whether following a user requires their approval.
+func (r *MemoryFollowersRepository) SetPrivate(ctx context.Context, userID uuid.UUID, private bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.private[userID] = private
+	return nil
+}
+
+// IsPrivate reports whether following a user requires their approval.
+func (r *MemoryFollowersRepository) IsPrivate(ctx context.Context, userID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.private[userID], nil
+}
+
+// CreateFollowRequest saves a follow request unless the sender already asked to follow the receiver.
+// It returns the stored request and whether this call created it, or ErrBlocked if either user blocks the other.
+func (r *MemoryFollowersRepository) CreateFollowRequest(ctx context.Context, request *model.FollowRequest) (*model.FollowRequest, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.blockedEitherWay(request.SenderID, request.ReceiverID) {
+		return nil, false, ErrBlocked
+	}
+
+	pair := userPair{from: request.SenderID, to: request.ReceiverID}
+	if existing, ok := r.requests[pair]; ok {
+		return &existing, false, nil
+	}
+
+	saved := *request
+	r.requests[pair] = saved
+	return &saved, true, nil
+}
+
+// GetFollowRequestByID retrieves a follow request by its ID.
+func (r *MemoryFollowersRepository) GetFollowRequestByID(ctx context.Context, id uuid.UUID) (*model.FollowRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, request := range r.requests {
+		if request.ID == id {
+			return &request, nil
+		}
+	}
+	return nil, ErrFollowRequestNotFound
+}
+
+// ApproveFollowRequest replaces a follow request with a follow relationship created at approvedAt.
+// If the sender already follows the receiver, the existing follow is kept.
+// If either user blocks the other, the request is deleted and ErrBlocked returned.
+func (r *MemoryFollowersRepository) ApproveFollowRequest(ctx context.Context, id, followID uuid.UUID, approvedAt time.Time) (*model.Follow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for pair, request := range r.requests {
+		if request.ID != id {
+			continue
+		}
+		delete(r.requests, pair)
+
+		if r.blockedEitherWay(request.SenderID, request.ReceiverID) {
+			return nil, ErrBlocked
+		}
+
+		follow, ok := r.follows[pair]
+		if !ok {
+			follow = model.Follow{
+				ID:         followID,
+				SenderID:   request.SenderID,
+				ReceiverID: request.ReceiverID,
+				CreatedAt:  approvedAt,
+			}
+			r.follows[pair] = follow
+		}
+		return &follow, nil
+	}
+	return nil, ErrFollowRequestNotFound
+}
+
+// DeleteFollowRequest deletes a follow request by its ID.
+// It reports whether a request was deleted.
+func (r *MemoryFollowersRepository) DeleteFollowRequest(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for pair, request := range r.requests {
+		if request.ID == id {
+			delete(r.requests, pair)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetIncomingFollowRequests retrieves one page of the follow requests sent to a given user, newest first.
+// It reports whether more requests exist beyond the page in the read direction.
+func (r *MemoryFollowersRepository) GetIncomingFollowRequests(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.FollowRequest, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	requests, hasMore := followRequestsPage(r.requests, func(pair userPair) bool { return pair.to == userID }, page)
+	return requests, hasMore, nil
+}
+
+// GetOutgoingFollowRequests retrieves one page of the follow requests sent by a given user, newest first.
+// It reports whether more requests exist beyond the page in the read direction.
+func (r *MemoryFollowersRepository) GetOutgoingFollowRequests(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.FollowRequest, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	requests, hasMore := followRequestsPage(r.requests, func(pair userPair) bool { return pair.from == userID }, page)
+	return requests, hasMore, nil
+}
+
+// CreateBlock saves a block relationship unless the blocker already blocks the blocked user,
+// and deletes the follows and follow requests between both users.
+// It returns the stored block and whether this call created it.
+func (r *MemoryFollowersRepository) CreateBlock(ctx context.Context, block *model.Block) (*model.Block, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pair := userPair{from: block.BlockerID, to: block.BlockedID}
+	reverse := userPair{from: block.BlockedID, to: block.BlockerID}
+	for _, severed := range []userPair{pair, reverse} {
+		delete(r.follows, severed)
+		delete(r.requests, severed)
+	}
+
+	if existing, ok := r.blocks[pair]; ok {
+		return &existing, false, nil
+	}
+
+	saved := *block
+	r.blocks[pair] = saved
+	return &saved, true, nil
+}
+
+// DeleteBlock deletes the block relationship from a blocker to a blocked user.
+// It reports whether a relationship was deleted.
+func (r *MemoryFollowersRepository) DeleteBlock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pair := userPair{from: blockerID, to: blockedID}
+	_, ok := r.blocks[pair]
+	delete(r.blocks, pair)
+	return ok, nil
+}
+
+// IsBlocked reports whether a blocker blocks a blocked user.
+func (r *MemoryFollowersRepository) IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.blocks[userPair{from: blockerID, to: blockedID}]
+	return ok, nil
+}
+
+// SaveMute creates a mute, or resets the creation and expiry times of an existing one.
+func (r *MemoryFollowersRepository) SaveMute(ctx context.Context, userID uuid.UUID, mute *model.Mute) error {
+	if _, ok := muteTargets[mute.Kind]; !ok {
+		return fmt.Errorf("unknown mute kind %q", mute.Kind)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := *mute
+	if mute.ExpiresAt != nil {
+		expiresAt := *mute.ExpiresAt
+		saved.ExpiresAt = &expiresAt
+	}
+	r.mutes[muteEntry{userID: userID, kind: mute.Kind, targetID: mute.TargetID}] = saved
+	return nil
+}
+
+// DeleteMute deletes a mute of the given kind, expired or not.
+// It reports whether a mute that was still active at now was deleted.
+func (r *MemoryFollowersRepository) DeleteMute(ctx context.Context, userID uuid.UUID, kind string, targetID uuid.UUID, now time.Time) (bool, error) {
+	if _, ok := muteTargets[kind]; !ok {
+		return false, fmt.Errorf("unknown mute kind %q", kind)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := muteEntry{userID: userID, kind: kind, targetID: targetID}
+	mute, ok := r.mutes[entry]
+	delete(r.mutes, entry)
+	return ok && muteActive(mute, now), nil
+}
+
+// GetMutes retrieves the mutes of a user that are still active at now, newest first.
+func (r *MemoryFollowersRepository) GetMutes(ctx context.Context, userID uuid.UUID, now time.Time) ([]model.Mute, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mutes := []model.Mute{}
+	for entry, mute := range r.mutes {
+		if entry.userID == userID && muteActive(mute, now) {
+			mutes = append(mutes, mute)
+		}
+	}
+
+	sort.Slice(mutes, func(i, j int) bool {
+		return mutes[i].CreatedAt.After(mutes[j].CreatedAt)
+	})
+	return mutes, nil
+}
+
+// muteActive reports whether a mute has not expired at now.
+func muteActive(mute model.Mute, now time.Time) bool {
+	return mute.ExpiresAt == nil || mute.ExpiresAt.After(now)
+}
+
+// GetRelationships retrieves whether a user and each of the other users follow each other.
+func (r *MemoryFollowersRepository) GetRelationships(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) ([]model.Relationship, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	relationships := make([]model.Relationship, 0, len(otherIDs))
+	for _, otherID := range otherIDs {
+		outgoing := userPair{from: userID, to: otherID}
+		incoming := userPair{from: otherID, to: userID}
+
+		_, following := r.follows[outgoing]
+		_, followedBy := r.follows[incoming]
+		_, requested := r.requests[outgoing]
+		_, blocking := r.blocks[outgoing]
+		_, blockedBy := r.blocks[incoming]
+
+		relationships = append(relationships, model.Relationship{
+			UserID:     otherID,
+			Following:  following,
+			FollowedBy: followedBy,
+			Requested:  requested,
+			Blocking:   blocking,
+			BlockedBy:  blockedBy,
+		})
+	}
+	return relationships, nil
+}
+
+// GetFollowers retrieves one page of the followers of a given user ID, newest first.
+// It reports whether more follows exist beyond the page in the read direction.
+func (r *MemoryFollowersRepository) GetFollowers(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Follow, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	follows, hasMore := followsPage(r.follows, func(pair userPair) bool { return pair.to == userID }, page)
+	return follows, hasMore, nil
+}
+
+// GetFollowing retrieves one page of the users a given user ID is following, newest first.
+// It reports whether more follows exist beyond the page in the read direction.
+func (r *MemoryFollowersRepository) GetFollowing(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Follow, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	follows, hasMore := followsPage(r.follows, func(pair userPair) bool { return pair.from == userID }, page)
+	return follows, hasMore, nil
+}
+
+// GetMutuals retrieves one page of the users who follow a user and are followed back by them,
+// along with the total number of such users on the first page.
+// It reports whether more users exist beyond the page in the direction it was read.
+func (r *MemoryFollowersRepository) GetMutuals(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]uuid.UUID, *int, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var mutuals []uuid.UUID
+	for pair := range r.follows {
+		if pair.from != userID {
+			continue
+		}
+		if _, ok := r.follows[userPair{from: pair.to, to: userID}]; ok {
+			mutuals = append(mutuals, pair.to)
+		}
+	}
+
+	userIDs, hasMore := usersPage(mutuals, page)
+	return userIDs, firstPageCount(len(mutuals), page), hasMore, nil
+}
+
+// GetKnownFollowers retrieves one page of the followers of a user that the viewer follows,
+// along with the total number of such followers on the first page.
+// It reports whether more users exist beyond the page in the direction it was read.
+func (r *MemoryFollowersRepository) GetKnownFollowers(ctx context.Context, viewerID, userID uuid.UUID, page pagination.Request) ([]uuid.UUID, *int, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var known []uuid.UUID
+	for pair := range r.follows {
+		if pair.from != viewerID {
+			continue
+		}
+		if _, ok := r.follows[userPair{from: pair.to, to: userID}]; ok {
+			known = append(known, pair.to)
+		}
+	}
+
+	userIDs, hasMore := usersPage(known, page)
+	return userIDs, firstPageCount(len(known), page), hasMore, nil
+}
+
+// firstPageCount returns the size of a list of users on its first page, and nil on the others,
+// as the Neo4j repository only counts the first page.
+func firstPageCount(count int, page pagination.Request) *int {
+	if page.Anchor() != nil {
+		return nil
+	}
+	return &count
+}
+
+// GetSuggestions retrieves one page of the friends of friends of a user, ranked by how many
+// of the user's followees follow them, then by ID. Users the user already follows or has
+// a block with are left out. Only forward pages are supported.
+// It reports whether more suggestions exist beyond the page.
+func (r *MemoryFollowersRepository) GetSuggestions(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Suggestion, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	followedBy := map[uuid.UUID][]uuid.UUID{}
+	for first := range r.follows {
+		if first.from != userID {
+			continue
+		}
+		for second := range r.follows {
+			candidate := second.to
+			if second.from != first.to || candidate == userID {
+				continue
+			}
+			if _, ok := r.follows[userPair{from: userID, to: candidate}]; ok {
+				continue
+			}
+			if r.blockedEitherWay(userID, candidate) {
+				continue
+			}
+			followedBy[candidate] = append(followedBy[candidate], first.to)
+		}
+	}
+
+	suggestions := make([]model.Suggestion, 0, len(followedBy))
+	for candidate, followees := range followedBy {
+		sortUserIDs(followees)
+		suggestions = append(suggestions, model.Suggestion{
+			UserID:      candidate,
+			MutualCount: len(followees),
+			FollowedBy:  followees[:min(len(followees), 3)],
+		})
+	}
+
+	// Highest scores first, lowest IDs first among equal scores
+	compare := func(suggestion model.Suggestion, cursor pagination.Cursor) int {
+		if suggestion.MutualCount != cursor.Score {
+			return cursor.Score - suggestion.MutualCount
+		}
+		return bytes.Compare(suggestion.UserID[:], cursor.ID[:])
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return compare(suggestions[i], pagination.Cursor{Score: suggestions[j].MutualCount, ID: suggestions[j].UserID}) < 0
+	})
+
+	suggestions, hasMore := pagination.Slice(suggestions, pagination.Request{Limit: page.Limit, After: page.After}, compare)
+	return suggestions, hasMore, nil
+}
+
+// blockedEitherWay reports whether either user blocks the other.
+func (r *MemoryFollowersRepository) blockedEitherWay(a, b uuid.UUID) bool {
+	_, blocking := r.blocks[userPair{from: a, to: b}]
+	_, blockedBy := r.blocks[userPair{from: b, to: a}]
+	return blocking || blockedBy
+}
+
+// GetFollowersCount retrieves the count of followers for a given user ID.
+func (r *MemoryFollowersRepository) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for pair := range r.follows {
+		if pair.to == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetFollowingCount retrieves the count of users a given user is following.
+func (r *MemoryFollowersRepository) GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for pair := range r.follows {
+		if pair.from == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// followsPage cuts one page, newest first, out of the follows whose users match.
+func followsPage(follows map[userPair]model.Follow, match func(pair userPair) bool, page pagination.Request) ([]model.Follow, bool) {
+	matched := []model.Follow{}
+	for pair, follow := range follows {
+		if match(pair) {
+			matched = append(matched, follow)
+		}
+	}
+
+	// Newest first, with the ID breaking ties the way Neo4j orders them
+	compare := func(follow model.Follow, cursor pagination.Cursor) int {
+		if c := cursor.CreatedAt.Compare(follow.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(cursor.ID[:], follow.ID[:])
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return compare(matched[i], pagination.Cursor{CreatedAt: matched[j].CreatedAt, ID: matched[j].ID}) < 0
+	})
+
+	return pagination.Slice(matched, page, compare)
+}
+
+// followRequestsPage cuts one page, newest first, out of the follow requests whose users match.
+func followRequestsPage(requests map[userPair]model.FollowRequest, match func(pair userPair) bool, page pagination.Request) ([]model.FollowRequest, bool) {
+	follows := make(map[userPair]model.Follow, len(requests))
+	for pair, request := range requests {
+		follows[pair] = model.Follow(request)
+	}
+
+	matched, hasMore := followsPage(follows, match, page)
+	matchedRequests := make([]model.FollowRequest, 0, len(matched))
+	for _, follow := range matched {
+		matchedRequests = append(matchedRequests, model.FollowRequest(follow))
+	}
+	return matchedRequests, hasMore
+}
+
+// usersPage cuts one page, by ascending ID, out of a list of users.
+func usersPage(userIDs []uuid.UUID, page pagination.Request) ([]uuid.UUID, bool) {
+	sortUserIDs(userIDs)
+	return pagination.Slice(userIDs, page, func(userID uuid.UUID, cursor pagination.Cursor) int {
+		return bytes.Compare(userID[:], cursor.ID[:])
+	})
+}
+
+// sortUserIDs sorts user IDs in ascending order, the order of their string form.
+func sortUserIDs(userIDs []uuid.UUID) {
+	sort.Slice(userIDs, func(i, j int) bool {
+		return bytes.Compare(userIDs[i][:], userIDs[j][:]) < 0
+	})
+}
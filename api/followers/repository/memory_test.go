@@ -0,0 +1,13 @@
+package repository_test
+
+import (
+	"hornet/api/followers/repository"
+	"hornet/api/followers/repository/repositorytest"
+	"testing"
+)
+
+func TestMemoryFollowersRepository(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.FollowersRepository {
+		return repository.NewMemoryFollowersRepository()
+	})
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Migration is a versioned change to the Neo4j schema. Its statements must be idempotent,
+// so that a migration interrupted before being recorded can safely run again.
+type Migration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// migrations lists the schema changes in the order they are applied.
+// Append new migrations with the next version, never edit applied ones.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "unique user ids",
+		Statements: []string{
+			`CREATE CONSTRAINT user_id IF NOT EXISTS
+			FOR (u:User) REQUIRE u.id IS UNIQUE`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "unique follow keys and indexed follow ids",
+		Statements: []string{
+			`CREATE CONSTRAINT follow_key IF NOT EXISTS
+			FOR ()-[f:FOLLOW]-() REQUIRE f.key IS UNIQUE`,
+			`CREATE INDEX follow_id IF NOT EXISTS
+			FOR ()-[f:FOLLOW]-() ON (f.id)`,
+			`CREATE INDEX follow_created_at IF NOT EXISTS
+			FOR ()-[f:FOLLOW]-() ON (f.created_at)`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "unique follow request keys and indexed follow request ids",
+		Statements: []string{
+			`CREATE CONSTRAINT follow_request_key IF NOT EXISTS
+			FOR ()-[r:FOLLOW_REQUEST]-() REQUIRE r.key IS UNIQUE`,
+			`CREATE INDEX follow_request_id IF NOT EXISTS
+			FOR ()-[r:FOLLOW_REQUEST]-() ON (r.id)`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "unique block keys",
+		Statements: []string{
+			`CREATE CONSTRAINT block_key IF NOT EXISTS
+			FOR ()-[b:BLOCKS]-() REQUIRE b.key IS UNIQUE`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "unique mute keys and conversation ids",
+		Statements: []string{
+			`CREATE CONSTRAINT mute_key IF NOT EXISTS
+			FOR ()-[m:MUTES]-() REQUIRE m.key IS UNIQUE`,
+			`CREATE CONSTRAINT mute_conversation_key IF NOT EXISTS
+			FOR ()-[m:MUTES_CONVERSATION]-() REQUIRE m.key IS UNIQUE`,
+			`CREATE CONSTRAINT conversation_id IF NOT EXISTS
+			FOR (c:Conversation) REQUIRE c.id IS UNIQUE`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "follows and follow requests seekable by user and creation time",
+		Statements: []string{
+			`MATCH (sender:User)-[f:FOLLOW|FOLLOW_REQUEST]->(receiver:User)
+			WHERE f.sender_id IS NULL OR f.receiver_id IS NULL
+			CALL {
+				WITH sender, receiver, f
+				SET f.sender_id = sender.id, f.receiver_id = receiver.id
+			} IN TRANSACTIONS OF 10000 ROWS`,
+			`CREATE INDEX follow_sender_created_at IF NOT EXISTS
+			FOR ()-[f:FOLLOW]-() ON (f.sender_id, f.created_at, f.id)`,
+			`CREATE INDEX follow_receiver_created_at IF NOT EXISTS
+			FOR ()-[f:FOLLOW]-() ON (f.receiver_id, f.created_at, f.id)`,
+			`CREATE INDEX follow_request_sender_created_at IF NOT EXISTS
+			FOR ()-[r:FOLLOW_REQUEST]-() ON (r.sender_id, r.created_at, r.id)`,
+			`CREATE INDEX follow_request_receiver_created_at IF NOT EXISTS
+			FOR ()-[r:FOLLOW_REQUEST]-() ON (r.receiver_id, r.created_at, r.id)`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "follows seekable by sender and receiver",
+		Statements: []string{
+			`CREATE INDEX follow_sender_receiver IF NOT EXISTS
+			FOR ()-[f:FOLLOW]-() ON (f.sender_id, f.receiver_id)`,
+		},
+	},
+}
+
+// Migrate applies the migrations that are not yet recorded in the graph, in version order,
+// and records each one as a (:SchemaMigration) node once its statements succeed.
+// It returns the migrations applied by this call.
+func (r *Neo4jFollowersRepository) Migrate(ctx context.Context) ([]Migration, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	// Concurrent runners may both apply a migration, its statements being idempotent,
+	// but record it only once
+	if err := runSchemaStatement(ctx, session, `
+		CREATE CONSTRAINT schema_migration_version IF NOT EXISTS
+		FOR (m:SchemaMigration) REQUIRE m.version IS UNIQUE
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create the schema migrations constraint: %w", err)
+	}
+
+	applied, err := r.appliedMigrations(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	var ran []Migration
+	for _, migration := range migrations {
+		if applied[migration.Version] {
+			continue
+		}
+
+		// Schema changes cannot share a transaction with data writes, each runs on its own,
+		// and backfills batch their writes in transactions of their own
+		for _, statement := range migration.Statements {
+			if err := runSchemaStatement(ctx, session, statement); err != nil {
+				return ran, fmt.Errorf("failed to apply migration %d (%s): %w", migration.Version, migration.Description, err)
+			}
+		}
+
+		if err := recordMigration(ctx, session, migration); err != nil {
+			return ran, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+		ran = append(ran, migration)
+	}
+
+	return ran, nil
+}
+
+// appliedMigrations retrieves the versions of the migrations recorded in the graph.
+func (r *Neo4jFollowersRepository) appliedMigrations(ctx context.Context, session neo4j.SessionWithContext) (map[int]bool, error) {
+	versions, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, "MATCH (m:SchemaMigration) RETURN m.version AS version", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		versions := map[int]bool{}
+		for result.Next(ctx) {
+			versionVal, _ := result.Record().Get("version")
+			versions[int(versionVal.(int64))] = true
+		}
+		return versions, result.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return versions.(map[int]bool), nil
+}
+
+// recordMigration marks a migration as applied.
+func recordMigration(ctx context.Context, session neo4j.SessionWithContext, migration Migration) error {
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MERGE (m:SchemaMigration {version: $version})
+			ON CREATE SET m.description = $description, m.applied_at = $appliedAt
+		`
+		params := map[string]interface{}{
+			"version":     migration.Version,
+			"description": migration.Description,
+			"appliedAt":   time.Now().UTC(),
+		}
+		_, err := tx.Run(ctx, query, params)
+		return nil, err
+	})
+
+	return err
+}
+
+// runSchemaStatement runs a schema statement, or a backfill batching its own transactions, in an auto-commit transaction.
+func runSchemaStatement(ctx context.Context, session neo4j.SessionWithContext, statement string) error {
+	result, err := session.Run(ctx, statement, nil)
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume(ctx)
+	return err
+}
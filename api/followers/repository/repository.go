@@ -2,65 +2,816 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"hornet/api/followers/model"
-	"sync"
+	"hornet/common/pagination"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
-// FollowersRepository defines the methods for interacting with the database for followers.
-type FollowersRepository struct {
+var (
+	// ErrFollowNotFound is returned when no follow relationship matches the given ID.
+	ErrFollowNotFound = errors.New("follow not found")
+
+	// ErrFollowRequestNotFound is returned when no follow request matches the given ID.
+	ErrFollowRequestNotFound = errors.New("follow request not found")
+
+	// ErrBlocked is returned when following a user across a block, in either direction.
+	ErrBlocked = errors.New("users are blocked")
+)
+
+// FollowersRepository defines the methods for storing the social graph: follows, follow requests,
+// blocks, mutes and privacy settings. Neo4jFollowersRepository is the production backend,
+// MemoryFollowersRepository serves tests and local runs.
+type FollowersRepository interface {
+	// CreateFollow saves a follow relationship unless the sender already follows the receiver,
+	// and deletes the pending follow request between them. It returns ErrBlocked if either user blocks the other.
+	// It returns the stored follow and whether this call created it.
+	CreateFollow(ctx context.Context, follow *model.Follow) (*model.Follow, bool, error)
+
+	// GetFollowByID retrieves a follow relationship by its ID, or returns ErrFollowNotFound.
+	GetFollowByID(ctx context.Context, id uuid.UUID) (*model.Follow, error)
+
+	// GetFollowBetween retrieves the follow relationship from a sender to a receiver, or returns ErrFollowNotFound.
+	GetFollowBetween(ctx context.Context, senderID, receiverID uuid.UUID) (*model.Follow, error)
+
+	// DeleteFollow deletes a follow relationship by its ID.
+	DeleteFollow(ctx context.Context, id uuid.UUID) error
+
+	// DeleteFollowBetween deletes the follow relationship from a sender to a receiver.
+	// It reports whether a relationship was deleted.
+	DeleteFollowBetween(ctx context.Context, senderID, receiverID uuid.UUID) (bool, error)
+
+	// RepairDuplicateFollows merges duplicate follow relationships between the same users.
+	// It returns the number of relationships removed.
+	RepairDuplicateFollows(ctx context.Context) (int, error)
+
+	// SetPrivate sets whether following a user requires their approval.
+	SetPrivate(ctx context.Context, userID uuid.UUID, private bool) error
+
+	// IsPrivate reports whether following a user requires their approval. Unknown users are public.
+	IsPrivate(ctx context.Context, userID uuid.UUID) (bool, error)
+
+	// CreateFollowRequest saves a follow request unless the sender already asked to follow the receiver.
+	// It returns ErrBlocked if either user blocks the other.
+	// It returns the stored request and whether this call created it.
+	CreateFollowRequest(ctx context.Context, request *model.FollowRequest) (*model.FollowRequest, bool, error)
+
+	// GetFollowRequestByID retrieves a follow request by its ID, or returns ErrFollowRequestNotFound.
+	GetFollowRequestByID(ctx context.Context, id uuid.UUID) (*model.FollowRequest, error)
+
+	// ApproveFollowRequest replaces a follow request with a follow relationship created at approvedAt,
+	// keeping the existing follow if there is one. It returns ErrFollowRequestNotFound if the request is gone,
+	// and ErrBlocked, deleting the request, if either user blocks the other.
+	ApproveFollowRequest(ctx context.Context, id, followID uuid.UUID, approvedAt time.Time) (*model.Follow, error)
+
+	// DeleteFollowRequest deletes a follow request by its ID.
+	// It reports whether a request was deleted.
+	DeleteFollowRequest(ctx context.Context, id uuid.UUID) (bool, error)
+
+	// GetIncomingFollowRequests retrieves one page of the follow requests sent to a user, newest first.
+	// It reports whether more requests exist beyond the page in the read direction.
+	GetIncomingFollowRequests(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.FollowRequest, bool, error)
+
+	// GetOutgoingFollowRequests retrieves one page of the follow requests sent by a user, newest first.
+	// It reports whether more requests exist beyond the page in the read direction.
+	GetOutgoingFollowRequests(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.FollowRequest, bool, error)
+
+	// CreateBlock saves a block relationship unless the blocker already blocks the blocked user,
+	// and deletes the follows and follow requests between both users.
+	// It returns the stored block and whether this call created it.
+	CreateBlock(ctx context.Context, block *model.Block) (*model.Block, bool, error)
+
+	// DeleteBlock deletes the block relationship from a blocker to a blocked user.
+	// It reports whether a relationship was deleted.
+	DeleteBlock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+
+	// IsBlocked reports whether a blocker blocks a blocked user.
+	IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+
+	// SaveMute creates a mute, or resets the creation and expiry times of an existing one.
+	SaveMute(ctx context.Context, userID uuid.UUID, mute *model.Mute) error
+
+	// DeleteMute deletes a mute of the given kind, expired or not.
+	// It reports whether a mute that was still active at now was deleted.
+	DeleteMute(ctx context.Context, userID uuid.UUID, kind string, targetID uuid.UUID, now time.Time) (bool, error)
+
+	// GetMutes retrieves the mutes of a user that are still active at now, newest first.
+	GetMutes(ctx context.Context, userID uuid.UUID, now time.Time) ([]model.Mute, error)
+
+	// GetRelationships retrieves the relationships between a user and each of the other users, in order.
+	GetRelationships(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) ([]model.Relationship, error)
+
+	// GetFollowers retrieves one page of the follows a user received, newest first.
+	// It reports whether more follows exist beyond the page in the read direction.
+	GetFollowers(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Follow, bool, error)
+
+	// GetFollowing retrieves one page of the follows a user sent, newest first.
+	// It reports whether more follows exist beyond the page in the read direction.
+	GetFollowing(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Follow, bool, error)
+
+	// GetMutuals retrieves one page of the users who follow a user and are followed back, by ID,
+	// along with the total number of such users on the first page, nil on the others.
+	// It reports whether more users exist beyond the page in the direction it was read.
+	GetMutuals(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]uuid.UUID, *int, bool, error)
+
+	// GetKnownFollowers retrieves one page of the followers of a user that the viewer follows, by ID,
+	// along with the total number of such followers on the first page, nil on the others.
+	// It reports whether more users exist beyond the page in the direction it was read.
+	GetKnownFollowers(ctx context.Context, viewerID, userID uuid.UUID, page pagination.Request) ([]uuid.UUID, *int, bool, error)
+
+	// GetSuggestions retrieves one page of the friends of friends of a user, ranked by how many
+	// of the user's followees follow them, then by ID. Only forward pages are supported.
+	// It reports whether more suggestions exist beyond the page.
+	GetSuggestions(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Suggestion, bool, error)
+
+	// GetFollowersCount retrieves the number of followers of a user.
+	GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// GetFollowingCount retrieves the number of users a user follows.
+	GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// Neo4jFollowersRepository stores the social graph in Neo4j.
+type Neo4jFollowersRepository struct {
 	driver neo4j.DriverWithContext
 }
 
-// Global variables for the singleton instance of FollowersRepository.
-var (
-	followersRepositoryInstance *FollowersRepository
-	followersOnce               sync.Once
-)
+// NewNeo4jFollowersRepository creates a Neo4jFollowersRepository using the given driver.
+func NewNeo4jFollowersRepository(driver neo4j.DriverWithContext) *Neo4jFollowersRepository {
+	return &Neo4jFollowersRepository{
+		driver: driver,
+	}
+}
+
+// followKey returns the unique key of the follow relationship between two users.
+func followKey(senderID, receiverID uuid.UUID) string {
+	return senderID.String() + ":" + receiverID.String()
+}
+
+// CreateFollow saves a follow relationship unless the sender already follows the receiver.
+// It returns the stored follow and whether this call created it, or ErrBlocked if either user blocks the other.
+func (r *Neo4jFollowersRepository) CreateFollow(ctx context.Context, follow *model.Follow) (*model.Follow, bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	create := func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		// Writing to both users locks them until the transaction ends, so a concurrent block
+		// either commits before the check or waits for the follow to commit and severs it
+		query := `
+			MERGE (sender:User {id: $senderID})
+			MERGE (receiver:User {id: $receiverID})
+			SET sender._lock = true, receiver._lock = true
+			REMOVE sender._lock, receiver._lock
+			WITH sender, receiver
+			WHERE NOT EXISTS { (sender)-[:BLOCKS]-(receiver) }
+			MERGE (sender)-[f:FOLLOW]->(receiver)
+			ON CREATE SET f.id = $id, f.created_at = $createdAt, f.key = $key,
+				f.sender_id = sender.id, f.receiver_id = receiver.id
+			WITH sender, receiver, f
+			OPTIONAL MATCH (sender)-[r:FOLLOW_REQUEST]->(receiver)
+			DELETE r
+			RETURN f.id AS id, f.created_at AS createdAt
+		`
+		params := map[string]interface{}{
+			"id":         follow.ID.String(),
+			"senderID":   follow.SenderID.String(),
+			"receiverID": follow.ReceiverID.String(),
+			"createdAt":  follow.CreatedAt,
+			"key":        followKey(follow.SenderID, follow.ReceiverID),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			return nil, ErrBlocked
+		}
+
+		record := result.Record()
+		idVal, _ := record.Get("id")
+		createdAtVal, _ := record.Get("createdAt")
+		return &model.Follow{
+			ID:         uuid.MustParse(idVal.(string)),
+			SenderID:   follow.SenderID,
+			ReceiverID: follow.ReceiverID,
+			CreatedAt:  createdAtVal.(time.Time),
+		}, nil
+	}
+
+	saved, err := session.ExecuteWrite(ctx, create)
+	if isConstraintViolation(err) {
+		// A concurrent request created the same follow first, this time MERGE finds it
+		saved, err = session.ExecuteWrite(ctx, create)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	savedFollow := saved.(*model.Follow)
+	return savedFollow, savedFollow.ID == follow.ID, nil
+}
+
+// SetPrivate sets whether following a user requires their approval.
+func (r *Neo4jFollowersRepository) SetPrivate(ctx context.Context, userID uuid.UUID, private bool) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MERGE (u:User {id: $userID})
+			SET u.private = $private
+		`
+		params := map[string]interface{}{
+			"userID":  userID.String(),
+			"private": private,
+		}
+		_, err := tx.Run(ctx, query, params)
+		return nil, err
+	})
+
+	return err
+}
+
+// IsPrivate reports whether following a user requires their approval.
+// Users without a privacy flag are public.
+func (r *Neo4jFollowersRepository) IsPrivate(ctx context.Context, userID uuid.UUID) (bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	private, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			OPTIONAL MATCH (u:User {id: $userID})
+			RETURN coalesce(u.private, false) AS private
+		`
+		params := map[string]interface{}{
+			"userID": userID.String(),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		privateVal, _ := record.Get("private")
+		return privateVal.(bool), nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return private.(bool), nil
+}
+
+// GetFollowBetween retrieves the follow relationship from a sender to a receiver.
+func (r *Neo4jFollowersRepository) GetFollowBetween(ctx context.Context, senderID, receiverID uuid.UUID) (*model.Follow, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	follow, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH (sender:User {id: $senderID})-[f:FOLLOW]->(receiver:User {id: $receiverID})
+			RETURN f.id AS id, sender.id AS senderID, receiver.id AS receiverID, f.created_at AS createdAt
+		`
+		params := map[string]interface{}{
+			"senderID":   senderID.String(),
+			"receiverID": receiverID.String(),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			return nil, ErrFollowNotFound
+		}
+
+		follow, ok := followFromRecord(result.Record())
+		if !ok {
+			return nil, ErrFollowNotFound
+		}
+		return &follow, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return follow.(*model.Follow), nil
+}
+
+// CreateFollowRequest saves a follow request unless the sender already asked to follow the receiver.
+// It returns the stored request and whether this call created it, or ErrBlocked if either user blocks the other.
+func (r *Neo4jFollowersRepository) CreateFollowRequest(ctx context.Context, request *model.FollowRequest) (*model.FollowRequest, bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	create := func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		// Both users are locked before the block check, like in CreateFollow
+		query := `
+			MERGE (sender:User {id: $senderID})
+			MERGE (receiver:User {id: $receiverID})
+			SET sender._lock = true, receiver._lock = true
+			REMOVE sender._lock, receiver._lock
+			WITH sender, receiver
+			WHERE NOT EXISTS { (sender)-[:BLOCKS]-(receiver) }
+			MERGE (sender)-[r:FOLLOW_REQUEST]->(receiver)
+			ON CREATE SET r.id = $id, r.created_at = $createdAt, r.key = $key,
+				r.sender_id = sender.id, r.receiver_id = receiver.id
+			RETURN r.id AS id, r.created_at AS createdAt
+		`
+		params := map[string]interface{}{
+			"id":         request.ID.String(),
+			"senderID":   request.SenderID.String(),
+			"receiverID": request.ReceiverID.String(),
+			"createdAt":  request.CreatedAt,
+			"key":        followKey(request.SenderID, request.ReceiverID),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			return nil, ErrBlocked
+		}
+
+		record := result.Record()
+		idVal, _ := record.Get("id")
+		createdAtVal, _ := record.Get("createdAt")
+		return &model.FollowRequest{
+			ID:         uuid.MustParse(idVal.(string)),
+			SenderID:   request.SenderID,
+			ReceiverID: request.ReceiverID,
+			CreatedAt:  createdAtVal.(time.Time),
+		}, nil
+	}
+
+	saved, err := session.ExecuteWrite(ctx, create)
+	if isConstraintViolation(err) {
+		// A concurrent request created the same follow request first, this time MERGE finds it
+		saved, err = session.ExecuteWrite(ctx, create)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	savedRequest := saved.(*model.FollowRequest)
+	return savedRequest, savedRequest.ID == request.ID, nil
+}
+
+// GetFollowRequestByID retrieves a follow request by its ID.
+func (r *Neo4jFollowersRepository) GetFollowRequestByID(ctx context.Context, id uuid.UUID) (*model.FollowRequest, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	request, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH (sender:User)-[f:FOLLOW_REQUEST {id: $id}]->(receiver:User)
+			RETURN f.id AS id, sender.id AS senderID, receiver.id AS receiverID, f.created_at AS createdAt
+		`
+		params := map[string]interface{}{
+			"id": id.String(),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			return nil, ErrFollowRequestNotFound
+		}
+
+		follow, ok := followFromRecord(result.Record())
+		if !ok {
+			return nil, ErrFollowRequestNotFound
+		}
+		request := model.FollowRequest(follow)
+		return &request, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return request.(*model.FollowRequest), nil
+}
+
+// ApproveFollowRequest replaces a follow request with a follow relationship created at approvedAt.
+// If the sender already follows the receiver, the existing follow is kept.
+// If either user blocks the other, the request is deleted and ErrBlocked returned.
+func (r *Neo4jFollowersRepository) ApproveFollowRequest(ctx context.Context, id, followID uuid.UUID, approvedAt time.Time) (*model.Follow, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	follow, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		// Both users are locked before the block check, like in CreateFollow, and the request
+		// is matched again once they are, in case a concurrent block or approval deleted it
+		query := `
+			MATCH (sender:User)-[:FOLLOW_REQUEST {id: $id}]->(receiver:User)
+			SET sender._lock = true, receiver._lock = true
+			REMOVE sender._lock, receiver._lock
+			WITH sender, receiver
+			MATCH (sender)-[r:FOLLOW_REQUEST {id: $id}]->(receiver)
+			WITH sender, receiver, r, EXISTS { (sender)-[:BLOCKS]-(receiver) } AS blocked
+			DELETE r
+			FOREACH (_ IN CASE WHEN blocked THEN [] ELSE [1] END |
+				MERGE (sender)-[f:FOLLOW]->(receiver)
+				ON CREATE SET f.id = $followID, f.created_at = $approvedAt, f.key = sender.id + ':' + receiver.id,
+					f.sender_id = sender.id, f.receiver_id = receiver.id
+			)
+			WITH sender, receiver, blocked
+			OPTIONAL MATCH (sender)-[f:FOLLOW]->(receiver)
+			RETURN blocked, f.id AS id, sender.id AS senderID, receiver.id AS receiverID, f.created_at AS createdAt
+		`
+		params := map[string]interface{}{
+			"id":         id.String(),
+			"followID":   followID.String(),
+			"approvedAt": approvedAt,
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			return nil, ErrFollowRequestNotFound
+		}
+
+		// Commit the deletion of a request across a block, without a follow
+		if blocked, _ := result.Record().Get("blocked"); blocked.(bool) {
+			return (*model.Follow)(nil), nil
+		}
+		follow, ok := followFromRecord(result.Record())
+		if !ok {
+			return nil, ErrFollowRequestNotFound
+		}
+		return &follow, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	if follow.(*model.Follow) == nil {
+		return nil, ErrBlocked
+	}
+	return follow.(*model.Follow), nil
+}
+
+// DeleteFollowRequest deletes a follow request by its ID.
+// It reports whether a request was deleted.
+func (r *Neo4jFollowersRepository) DeleteFollowRequest(ctx context.Context, id uuid.UUID) (bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	deleted, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH ()-[r:FOLLOW_REQUEST {id: $id}]->()
+			DELETE r
+			RETURN count(r) AS deleted
+		`
+		params := map[string]interface{}{
+			"id": id.String(),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		deletedVal, _ := record.Get("deleted")
+		return deletedVal.(int64) > 0, nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return deleted.(bool), nil
+}
+
+// CreateBlock saves a block relationship unless the blocker already blocks the blocked user,
+// and deletes the follows and follow requests between both users in the same transaction.
+// It returns the stored block and whether this call created it.
+func (r *Neo4jFollowersRepository) CreateBlock(ctx context.Context, block *model.Block) (*model.Block, bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	create := func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MERGE (blocker:User {id: $blockerID})
+			MERGE (blocked:User {id: $blockedID})
+			MERGE (blocker)-[b:BLOCKS]->(blocked)
+			ON CREATE SET b.created_at = $createdAt, b.key = $key
+			WITH blocker, blocked, b
+			OPTIONAL MATCH (blocker)-[r:FOLLOW|FOLLOW_REQUEST]-(blocked)
+			WITH b, collect(r) AS severed
+			FOREACH (r IN severed | DELETE r)
+			RETURN b.created_at AS createdAt
+		`
+		params := map[string]interface{}{
+			"blockerID": block.BlockerID.String(),
+			"blockedID": block.BlockedID.String(),
+			"createdAt": block.CreatedAt,
+			"key":       followKey(block.BlockerID, block.BlockedID),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		createdAtVal, _ := record.Get("createdAt")
+		return &model.Block{
+			BlockerID: block.BlockerID,
+			BlockedID: block.BlockedID,
+			CreatedAt: createdAtVal.(time.Time),
+		}, nil
+	}
+
+	saved, err := session.ExecuteWrite(ctx, create)
+	if isConstraintViolation(err) {
+		// A concurrent request created the same block first, this time MERGE finds it
+		saved, err = session.ExecuteWrite(ctx, create)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Blocks have no ID, an existing block keeps its original creation time
+	savedBlock := saved.(*model.Block)
+	return savedBlock, savedBlock.CreatedAt.Equal(block.CreatedAt), nil
+}
+
+// DeleteBlock deletes the block relationship from a blocker to a blocked user.
+// It reports whether a relationship was deleted.
+func (r *Neo4jFollowersRepository) DeleteBlock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	deleted, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH (:User {id: $blockerID})-[b:BLOCKS]->(:User {id: $blockedID})
+			DELETE b
+			RETURN count(b) AS deleted
+		`
+		params := map[string]interface{}{
+			"blockerID": blockerID.String(),
+			"blockedID": blockedID.String(),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		deletedVal, _ := record.Get("deleted")
+		return deletedVal.(int64) > 0, nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return deleted.(bool), nil
+}
+
+// IsBlocked reports whether a blocker blocks a blocked user.
+func (r *Neo4jFollowersRepository) IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	blocked, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			RETURN EXISTS { MATCH (:User {id: $blockerID})-[:BLOCKS]->(:User {id: $blockedID}) } AS blocked
+		`
+		params := map[string]interface{}{
+			"blockerID": blockerID.String(),
+			"blockedID": blockedID.String(),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		blockedVal, _ := record.Get("blocked")
+		return blockedVal.(bool), nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return blocked.(bool), nil
+}
+
+// muteTarget describes how a kind of mute is stored: the relationship type and the label of the muted node.
+type muteTarget struct {
+	relationship string
+	label        string
+}
+
+// muteTargets maps each kind of mute to its storage.
+var muteTargets = map[string]muteTarget{
+	model.MuteKindUser:         {relationship: "MUTES", label: "User"},
+	model.MuteKindConversation: {relationship: "MUTES_CONVERSATION", label: "Conversation"},
+}
+
+// SaveMute creates a mute, or resets the creation and expiry times of an existing one.
+func (r *Neo4jFollowersRepository) SaveMute(ctx context.Context, userID uuid.UUID, mute *model.Mute) error {
+	target, ok := muteTargets[mute.Kind]
+	if !ok {
+		return fmt.Errorf("unknown mute kind %q", mute.Kind)
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
 
-// NewFollowersRepository creates a new FollowersRepository instance if it doesn't exist.
-func NewFollowersRepository(driver neo4j.DriverWithContext) *FollowersRepository {
-	followersOnce.Do(func() {
-		followersRepositoryInstance = &FollowersRepository{
-			driver: driver,
+	save := func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := fmt.Sprintf(`
+			MERGE (u:User {id: $userID})
+			MERGE (t:%[1]s {id: $targetID})
+			MERGE (u)-[m:%[2]s]->(t)
+			ON CREATE SET m.key = $key
+			SET m.created_at = $createdAt, m.expires_at = $expiresAt
+		`, target.label, target.relationship)
+		params := map[string]interface{}{
+			"userID":    userID.String(),
+			"targetID":  mute.TargetID.String(),
+			"key":       followKey(userID, mute.TargetID),
+			"createdAt": mute.CreatedAt,
+			"expiresAt": nil,
 		}
-	})
-	return followersRepositoryInstance
+		if mute.ExpiresAt != nil {
+			params["expiresAt"] = *mute.ExpiresAt
+		}
+		_, err := tx.Run(ctx, query, params)
+		return nil, err
+	}
+
+	_, err := session.ExecuteWrite(ctx, save)
+	if isConstraintViolation(err) {
+		// A concurrent request created the same mute first, this time MERGE finds it
+		_, err = session.ExecuteWrite(ctx, save)
+	}
+	return err
 }
 
-// CreateFollow saves a new follow relationship to the database.
-func (r *FollowersRepository) CreateFollow(ctx context.Context, follow *model.Follow) (*model.Follow, error) {
+// DeleteMute deletes a mute of the given kind, expired or not.
+// It reports whether a mute that was still active at now was deleted.
+func (r *Neo4jFollowersRepository) DeleteMute(ctx context.Context, userID uuid.UUID, kind string, targetID uuid.UUID, now time.Time) (bool, error) {
+	target, ok := muteTargets[kind]
+	if !ok {
+		return false, fmt.Errorf("unknown mute kind %q", kind)
+	}
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+	deleted, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := fmt.Sprintf(`
+			MATCH (:User {id: $userID})-[m:%[2]s]->(:%[1]s {id: $targetID})
+			WITH m, m.expires_at IS NULL OR m.expires_at > $now AS active
+			DELETE m
+			RETURN count(CASE WHEN active THEN 1 END) AS deleted
+		`, target.label, target.relationship)
+		params := map[string]interface{}{
+			"userID":   userID.String(),
+			"targetID": targetID.String(),
+			"now":      now,
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		deletedVal, _ := record.Get("deleted")
+		return deletedVal.(int64) > 0, nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return deleted.(bool), nil
+}
+
+// GetMutes retrieves the mutes of a user that are still active at now, newest first.
+// Expired mutes are left in place and skipped, so they lapse without a cleanup job.
+func (r *Neo4jFollowersRepository) GetMutes(ctx context.Context, userID uuid.UUID, now time.Time) ([]model.Mute, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	mutes := []model.Mute{}
+	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		query := `
-			MERGE (sender:User {id: $senderID})
-			MERGE (receiver:User {id: $receiverID})
-			CREATE (sender)-[f:FOLLOW {id: $id, created_at: $createdAt}]->(receiver)
-			RETURN f
+			MATCH (:User {id: $userID})-[m:MUTES|MUTES_CONVERSATION]->(t)
+			WHERE m.expires_at IS NULL OR m.expires_at > $now
+			RETURN type(m) AS relationship, t.id AS targetID, m.created_at AS createdAt, m.expires_at AS expiresAt
+			ORDER BY m.created_at DESC
 		`
 		params := map[string]interface{}{
-			"id":         follow.ID.String(),
-			"senderID":   follow.SenderID.String(),
-			"receiverID": follow.ReceiverID.String(),
-			"createdAt":  follow.CreatedAt,
+			"userID": userID.String(),
+			"now":    now,
 		}
-		_, err := tx.Run(ctx, query, params)
-		return nil, err
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		for result.Next(ctx) {
+			record := result.Record()
+
+			relationshipVal, _ := record.Get("relationship")
+			targetIDVal, _ := record.Get("targetID")
+			createdAtVal, _ := record.Get("createdAt")
+			expiresAtVal, _ := record.Get("expiresAt")
+
+			mute := model.Mute{
+				Kind:      model.MuteKindUser,
+				TargetID:  uuid.MustParse(targetIDVal.(string)),
+				CreatedAt: createdAtVal.(time.Time),
+			}
+			if relationshipVal.(string) == muteTargets[model.MuteKindConversation].relationship {
+				mute.Kind = model.MuteKindConversation
+			}
+			if expiresAt, ok := expiresAtVal.(time.Time); ok {
+				mute.ExpiresAt = &expiresAt
+			}
+			mutes = append(mutes, mute)
+		}
+		return nil, result.Err()
 	})
 
 	if err != nil {
 		return nil, err
 	}
-	return follow, nil
+	return mutes, nil
+}
+
+// RepairDuplicateFollows merges duplicate follow relationships between the same users,
+// keeping the one with the earliest created_at, and backfills the keys of the survivors.
+// It returns the number of relationships removed.
+func (r *Neo4jFollowersRepository) RepairDuplicateFollows(ctx context.Context) (int, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	removed, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH (sender:User)-[f:FOLLOW]->(receiver:User)
+			WITH sender, receiver, f ORDER BY f.created_at ASC
+			WITH sender, receiver, collect(f) AS follows
+			FOREACH (duplicate IN follows[1..] | DELETE duplicate)
+			WITH sender, receiver, follows[0] AS kept, size(follows) - 1 AS removed
+			SET kept.key = sender.id + ':' + receiver.id, kept.sender_id = sender.id, kept.receiver_id = receiver.id
+			RETURN sum(removed) AS removed
+		`
+		result, err := tx.Run(ctx, query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		removedVal, _ := record.Get("removed")
+		return int(removedVal.(int64)), nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return removed.(int), nil
+}
+
+// isConstraintViolation reports whether err is Neo4j rejecting a write that breaks a constraint.
+func isConstraintViolation(err error) bool {
+	var neo4jErr *neo4j.Neo4jError
+	return errors.As(err, &neo4jErr) && neo4jErr.Code == "Neo.ClientError.Schema.ConstraintValidationFailed"
 }
 
 // DeleteFollow deletes a follow relationship by its ID.
-func (r *FollowersRepository) DeleteFollow(ctx context.Context, id uuid.UUID) error {
+func (r *Neo4jFollowersRepository) DeleteFollow(ctx context.Context, id uuid.UUID) error {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
@@ -79,77 +830,245 @@ func (r *FollowersRepository) DeleteFollow(ctx context.Context, id uuid.UUID) er
 	return err
 }
 
-// GetFollowers retrieves a list of followers for a given user ID.
-func (r *FollowersRepository) GetFollowers(ctx context.Context, userID uuid.UUID) ([]model.Follow, error) {
+// DeleteFollowBetween deletes the follow relationship from a sender to a receiver.
+// It reports whether a relationship was deleted.
+func (r *Neo4jFollowersRepository) DeleteFollowBetween(ctx context.Context, senderID, receiverID uuid.UUID) (bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	deleted, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH (:User {id: $senderID})-[f:FOLLOW]->(:User {id: $receiverID})
+			DELETE f
+			RETURN count(f) AS deleted
+		`
+		params := map[string]interface{}{
+			"senderID":   senderID.String(),
+			"receiverID": receiverID.String(),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := result.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		deletedVal, _ := record.Get("deleted")
+		return deletedVal.(int64) > 0, nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return deleted.(bool), nil
+}
+
+// GetRelationships retrieves whether a user and each of the other users follow each other.
+func (r *Neo4jFollowersRepository) GetRelationships(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) ([]model.Relationship, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
-	var followers []model.Follow
+	ids := make([]string, 0, len(otherIDs))
+	for _, id := range otherIDs {
+		ids = append(ids, id.String())
+	}
+
+	var relationships []model.Relationship
 	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		query := `
-			MATCH (receiver:User {id: $userID})<-[f:FOLLOW]-(sender:User)
-			RETURN f.id AS id, sender.id AS senderID, receiver.id AS receiverID, f.created_at AS createdAt
+			UNWIND $otherIDs AS otherID
+			RETURN otherID,
+				EXISTS { MATCH (:User {id: $userID})-[:FOLLOW]->(:User {id: otherID}) } AS following,
+				EXISTS { MATCH (:User {id: otherID})-[:FOLLOW]->(:User {id: $userID}) } AS followedBy,
+				EXISTS { MATCH (:User {id: $userID})-[:FOLLOW_REQUEST]->(:User {id: otherID}) } AS requested,
+				EXISTS { MATCH (:User {id: $userID})-[:BLOCKS]->(:User {id: otherID}) } AS blocking,
+				EXISTS { MATCH (:User {id: otherID})-[:BLOCKS]->(:User {id: $userID}) } AS blockedBy
 		`
 		params := map[string]interface{}{
-			"userID": userID.String(),
+			"userID":   userID.String(),
+			"otherIDs": ids,
 		}
 		result, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
 		}
 
-		// Iterate over the results.
 		for result.Next(ctx) {
 			record := result.Record()
 
-			// Retrieve each value and ensure it exists.
-			idVal, ok := record.Get("id")
-			if !ok {
-				continue
-			}
-			senderIDVal, ok := record.Get("senderID")
-			if !ok {
-				continue
-			}
-			receiverIDVal, ok := record.Get("receiverID")
-			if !ok {
-				continue
-			}
-			createdAtVal, ok := record.Get("createdAt")
-			if !ok {
-				continue
-			}
+			otherIDVal, _ := record.Get("otherID")
+			followingVal, _ := record.Get("following")
+			followedByVal, _ := record.Get("followedBy")
+			requestedVal, _ := record.Get("requested")
+			blockingVal, _ := record.Get("blocking")
+			blockedByVal, _ := record.Get("blockedBy")
 
-			followers = append(followers, model.Follow{
-				ID:         uuid.MustParse(idVal.(string)),
-				SenderID:   uuid.MustParse(senderIDVal.(string)),
-				ReceiverID: uuid.MustParse(receiverIDVal.(string)),
-				CreatedAt:  createdAtVal.(time.Time),
+			relationships = append(relationships, model.Relationship{
+				UserID:     uuid.MustParse(otherIDVal.(string)),
+				Following:  followingVal.(bool),
+				FollowedBy: followedByVal.(bool),
+				Requested:  requestedVal.(bool),
+				Blocking:   blockingVal.(bool),
+				BlockedBy:  blockedByVal.(bool),
 			})
 		}
-		return nil, nil
+		return nil, result.Err()
 	})
 
 	if err != nil {
 		return nil, err
 	}
-	return followers, nil
+	return relationships, nil
 }
 
-// GetFollowing retrieves a list of users a given user is following.
-func (r *FollowersRepository) GetFollowing(ctx context.Context, userID uuid.UUID) ([]model.Follow, error) {
+// GetFollowByID retrieves a follow relationship by its ID.
+func (r *Neo4jFollowersRepository) GetFollowByID(ctx context.Context, id uuid.UUID) (*model.Follow, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
-	var following []model.Follow
-	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+	follow, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		query := `
-			MATCH (sender:User {id: $userID})-[f:FOLLOW]->(receiver:User)
+			MATCH (sender:User)-[f:FOLLOW {id: $id}]->(receiver:User)
 			RETURN f.id AS id, sender.id AS senderID, receiver.id AS receiverID, f.created_at AS createdAt
 		`
 		params := map[string]interface{}{
-			"userID": userID.String(),
+			"id": id.String(),
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			return nil, ErrFollowNotFound
+		}
+
+		follow, ok := followFromRecord(result.Record())
+		if !ok {
+			return nil, ErrFollowNotFound
 		}
+		return &follow, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return follow.(*model.Follow), nil
+}
+
+// followFromRecord maps a record holding the id, senderID, receiverID and createdAt columns to a Follow.
+func followFromRecord(record *neo4j.Record) (model.Follow, bool) {
+	idVal, ok := record.Get("id")
+	if !ok {
+		return model.Follow{}, false
+	}
+	senderIDVal, ok := record.Get("senderID")
+	if !ok {
+		return model.Follow{}, false
+	}
+	receiverIDVal, ok := record.Get("receiverID")
+	if !ok {
+		return model.Follow{}, false
+	}
+	createdAtVal, ok := record.Get("createdAt")
+	if !ok {
+		return model.Follow{}, false
+	}
+
+	return model.Follow{
+		ID:         uuid.MustParse(idVal.(string)),
+		SenderID:   uuid.MustParse(senderIDVal.(string)),
+		ReceiverID: uuid.MustParse(receiverIDVal.(string)),
+		CreatedAt:  createdAtVal.(time.Time),
+	}, true
+}
+
+// GetFollowers retrieves one page of the followers of a given user ID, newest first.
+// It reports whether more follows exist beyond the page in the read direction.
+func (r *Neo4jFollowersRepository) GetFollowers(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Follow, bool, error) {
+	return r.getFollowsPage(ctx, "FOLLOW", "receiver_id", userID, page)
+}
+
+// GetFollowing retrieves one page of the users a given user ID is following, newest first.
+// It reports whether more follows exist beyond the page in the read direction.
+func (r *Neo4jFollowersRepository) GetFollowing(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Follow, bool, error) {
+	return r.getFollowsPage(ctx, "FOLLOW", "sender_id", userID, page)
+}
+
+// GetIncomingFollowRequests retrieves one page of the follow requests sent to a given user, newest first.
+// It reports whether more requests exist beyond the page in the read direction.
+func (r *Neo4jFollowersRepository) GetIncomingFollowRequests(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.FollowRequest, bool, error) {
+	return r.getFollowRequestsPage(ctx, "receiver_id", userID, page)
+}
+
+// GetOutgoingFollowRequests retrieves one page of the follow requests sent by a given user, newest first.
+// It reports whether more requests exist beyond the page in the read direction.
+func (r *Neo4jFollowersRepository) GetOutgoingFollowRequests(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.FollowRequest, bool, error) {
+	return r.getFollowRequestsPage(ctx, "sender_id", userID, page)
+}
+
+// getFollowRequestsPage reads follow requests with the same keyset as follows, which they mirror.
+func (r *Neo4jFollowersRepository) getFollowRequestsPage(ctx context.Context, userField string, userID uuid.UUID, page pagination.Request) ([]model.FollowRequest, bool, error) {
+	follows, hasMore, err := r.getFollowsPage(ctx, "FOLLOW_REQUEST", userField, userID, page)
+	if err != nil {
+		return nil, false, err
+	}
+
+	requests := make([]model.FollowRequest, 0, len(follows))
+	for _, follow := range follows {
+		requests = append(requests, model.FollowRequest(follow))
+	}
+	return requests, hasMore, nil
+}
+
+// getFollowsPage runs a keyset-paginated query over the relationships of the given type whose
+// userField, sender_id or receiver_id, is the user, ordered by created_at then id.
+//
+// The relationships carry the IDs of both users so the (userField, created_at, id) index of
+// their type seeks the page directly: the cursor bounds the range, the index provides the order,
+// and only limit + 1 relationships are read before their users are matched, however many
+// follows the user has.
+func (r *Neo4jFollowersRepository) getFollowsPage(ctx context.Context, relType, userField string, userID uuid.UUID, page pagination.Request) ([]model.Follow, bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	// Newest first, reading towards older follows unless the page goes backward
+	op, order := "<", "DESC"
+	if page.Backward() {
+		op, order = ">", "ASC"
+	}
+
+	params := map[string]interface{}{
+		"userID": userID.String(),
+		"limit":  page.Limit + 1,
+	}
+
+	// The first page reads from the newest follow. Later pages compare against the cursor with
+	// a range on created_at, rather than a disjunction with a null check that rules out any index seek.
+	where := ""
+	if anchor := page.Anchor(); anchor != nil {
+		where = fmt.Sprintf(`AND f.created_at %[1]s= $cursorTime
+				AND (f.created_at %[1]s $cursorTime OR f.id %[1]s $cursorID)`, op)
+		params["cursorTime"] = anchor.CreatedAt
+		params["cursorID"] = anchor.ID.String()
+	}
+
+	// Fetch one extra follow to find out whether another page exists
+	follows := []model.Follow{}
+	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := fmt.Sprintf(`
+			MATCH ()-[f:%[1]s]->()
+			USING INDEX f:%[1]s(%[2]s, created_at, id)
+			WHERE f.%[2]s = $userID %[3]s
+			WITH f
+			ORDER BY f.created_at %[4]s, f.id %[4]s
+			LIMIT $limit
+			MATCH (sender:User)-[f]->(receiver:User)
+			RETURN f.id AS id, sender.id AS senderID, receiver.id AS receiverID, f.created_at AS createdAt
+			ORDER BY f.created_at %[4]s, f.id %[4]s
+		`, relType, userField, where, order)
 		result, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
@@ -157,43 +1076,212 @@ func (r *FollowersRepository) GetFollowing(ctx context.Context, userID uuid.UUID
 
 		// Iterate over the results.
 		for result.Next(ctx) {
-			record := result.Record()
+			if follow, ok := followFromRecord(result.Record()); ok {
+				follows = append(follows, follow)
+			}
+		}
+		return nil, result.Err()
+	})
 
-			idVal, ok := record.Get("id")
-			if !ok {
-				continue
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(follows) > page.Limit
+	if hasMore {
+		follows = follows[:page.Limit]
+	}
+
+	// Backward pages are read oldest first, put them back in listing order
+	if page.Backward() {
+		for i, j := 0, len(follows)-1; i < j; i, j = i+1, j-1 {
+			follows[i], follows[j] = follows[j], follows[i]
+		}
+	}
+
+	return follows, hasMore, nil
+}
+
+// GetMutuals retrieves one page of the users who follow a user and are followed back by them,
+// along with the total number of such users on the first page.
+// It reports whether more users exist beyond the page in the direction it was read.
+func (r *Neo4jFollowersRepository) GetMutuals(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]uuid.UUID, *int, bool, error) {
+	return r.getUsersPage(ctx, userID, userID, page)
+}
+
+// GetKnownFollowers retrieves one page of the followers of a user that the viewer follows,
+// along with the total number of such followers on the first page.
+// It reports whether more users exist beyond the page in the direction it was read.
+func (r *Neo4jFollowersRepository) GetKnownFollowers(ctx context.Context, viewerID, userID uuid.UUID, page pagination.Request) ([]uuid.UUID, *int, bool, error) {
+	return r.getUsersPage(ctx, viewerID, userID, page)
+}
+
+// getUsersPage retrieves one page of the users followed by fromID who follow toID, ordered by ID.
+// The follows of fromID are seeked in the (sender_id, receiver_id) index from the cursor, in page
+// order, and checked for a follow to toID by its unique key, so the query stops once the page is full.
+// Counting reads every follow of fromID, so it only runs for the first page.
+func (r *Neo4jFollowersRepository) getUsersPage(ctx context.Context, fromID, toID uuid.UUID, page pagination.Request) ([]uuid.UUID, *int, bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	// Ascending IDs, reading towards lower IDs only when the page goes backward
+	op, order := ">", "ASC"
+	if page.Backward() {
+		op, order = "<", "DESC"
+	}
+
+	params := map[string]interface{}{
+		"fromID": fromID.String(),
+		"toID":   toID.String(),
+		"limit":  page.Limit + 1,
+	}
+
+	cursorCondition := ""
+	anchor := page.Anchor()
+	if anchor != nil {
+		cursorCondition = fmt.Sprintf("AND f.receiver_id %s $cursorID", op)
+		params["cursorID"] = anchor.ID.String()
+	}
+
+	// Fetch one extra user to find out whether another page exists
+	userIDs := []uuid.UUID{}
+	var count *int
+	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if anchor == nil {
+			result, err := tx.Run(ctx, `
+				MATCH ()-[f:FOLLOW]->()
+				USING INDEX f:FOLLOW(sender_id, receiver_id)
+				WHERE f.sender_id = $fromID
+					AND EXISTS { MATCH ()-[:FOLLOW {key: f.receiver_id + ':' + $toID}]->() }
+				RETURN count(f) AS count
+			`, params)
+			if err != nil {
+				return nil, err
 			}
-			senderIDVal, ok := record.Get("senderID")
-			if !ok {
-				continue
+			record, err := result.Single(ctx)
+			if err != nil {
+				return nil, err
 			}
-			receiverIDVal, ok := record.Get("receiverID")
-			if !ok {
-				continue
+			countVal, _ := record.Get("count")
+			total := int(countVal.(int64))
+			count = &total
+		}
+
+		query := fmt.Sprintf(`
+			MATCH ()-[f:FOLLOW]->()
+			USING INDEX f:FOLLOW(sender_id, receiver_id)
+			WHERE f.sender_id = $fromID %[1]s
+				AND EXISTS { MATCH ()-[:FOLLOW {key: f.receiver_id + ':' + $toID}]->() }
+			RETURN f.receiver_id AS userID
+			ORDER BY f.receiver_id %[2]s
+			LIMIT $limit
+		`, cursorCondition, order)
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		for result.Next(ctx) {
+			userIDVal, _ := result.Record().Get("userID")
+			userIDs = append(userIDs, uuid.MustParse(userIDVal.(string)))
+		}
+		return nil, result.Err()
+	})
+
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	hasMore := len(userIDs) > page.Limit
+	if hasMore {
+		userIDs = userIDs[:page.Limit]
+	}
+
+	// Backward pages are read in descending order, put them back in listing order
+	if page.Backward() {
+		for i, j := 0, len(userIDs)-1; i < j; i, j = i+1, j-1 {
+			userIDs[i], userIDs[j] = userIDs[j], userIDs[i]
+		}
+	}
+
+	return userIDs, count, hasMore, nil
+}
+
+// GetSuggestions retrieves one page of the friends of friends of a user, ranked by how many
+// of the user's followees follow them, then by ID. Users the user already follows or has
+// a block with are left out. Only forward pages are supported.
+// It reports whether more suggestions exist beyond the page.
+func (r *Neo4jFollowersRepository) GetSuggestions(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]model.Suggestion, bool, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	var cursorScore interface{}
+	var cursorID string
+	if page.After != nil {
+		cursorScore = page.After.Score
+		cursorID = page.After.ID.String()
+	}
+
+	// Fetch one extra suggestion to find out whether another page exists
+	suggestions := []model.Suggestion{}
+	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH (me:User {id: $userID})-[:FOLLOW]->(followee:User)-[:FOLLOW]->(candidate:User)
+			WHERE candidate <> me
+				AND NOT (me)-[:FOLLOW]->(candidate)
+				AND NOT (me)-[:BLOCKS]-(candidate)
+			WITH candidate, followee ORDER BY followee.id
+			WITH candidate, count(followee) AS score, collect(followee.id)[..3] AS followedBy
+			WHERE $cursorScore IS NULL
+				OR score < $cursorScore
+				OR (score = $cursorScore AND candidate.id > $cursorID)
+			RETURN candidate.id AS userID, score, followedBy
+			ORDER BY score DESC, candidate.id ASC
+			LIMIT $limit
+		`
+		params := map[string]interface{}{
+			"userID":      userID.String(),
+			"cursorScore": cursorScore,
+			"cursorID":    cursorID,
+			"limit":       page.Limit + 1,
+		}
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		for result.Next(ctx) {
+			record := result.Record()
+
+			userIDVal, _ := record.Get("userID")
+			scoreVal, _ := record.Get("score")
+			followedByVal, _ := record.Get("followedBy")
+
+			suggestion := model.Suggestion{
+				UserID:      uuid.MustParse(userIDVal.(string)),
+				MutualCount: int(scoreVal.(int64)),
 			}
-			createdAtVal, ok := record.Get("createdAt")
-			if !ok {
-				continue
+			for _, id := range followedByVal.([]interface{}) {
+				suggestion.FollowedBy = append(suggestion.FollowedBy, uuid.MustParse(id.(string)))
 			}
-
-			following = append(following, model.Follow{
-				ID:         uuid.MustParse(idVal.(string)),
-				SenderID:   uuid.MustParse(senderIDVal.(string)),
-				ReceiverID: uuid.MustParse(receiverIDVal.(string)),
-				CreatedAt:  createdAtVal.(time.Time),
-			})
+			suggestions = append(suggestions, suggestion)
 		}
-		return nil, nil
+		return nil, result.Err()
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	hasMore := len(suggestions) > page.Limit
+	if hasMore {
+		suggestions = suggestions[:page.Limit]
 	}
-	return following, nil
+	return suggestions, hasMore, nil
 }
 
 // GetFollowersCount retrieves the count of followers for a given user ID.
-func (r *FollowersRepository) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
+func (r *Neo4jFollowersRepository) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
@@ -229,7 +1317,7 @@ func (r *FollowersRepository) GetFollowersCount(ctx context.Context, userID uuid
 }
 
 // GetFollowingCount retrieves the count of users a given user is following.
-func (r *FollowersRepository) GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error) {
+func (r *Neo4jFollowersRepository) GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
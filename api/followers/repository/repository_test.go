@@ -0,0 +1,54 @@
+package repository_test
+
+import (
+	"context"
+	"hornet/api/followers/repository"
+	"hornet/api/followers/repository/repositorytest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// TestNeo4jFollowersRepository runs the repository checks against the Neo4j at NEO4J_URI, with
+// the credentials in NEO4J_USER and NEO4J_PASSWORD. It is skipped when NEO4J_URI is unset.
+//
+// Every check starts from an empty graph, so the database is wiped: never point it at real data.
+func TestNeo4jFollowersRepository(t *testing.T) {
+	uri := os.Getenv("NEO4J_URI")
+	if uri == "" {
+		t.Skip("NEO4J_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	driver, err := neo4j.NewDriverWithContext(uri, neo4j.BasicAuth(os.Getenv("NEO4J_USER"), os.Getenv("NEO4J_PASSWORD"), ""))
+	if err != nil {
+		t.Fatalf("NewDriverWithContext: %v", err)
+	}
+	t.Cleanup(func() {
+		driver.Close(context.Background())
+	})
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		t.Fatalf("VerifyConnectivity: %v", err)
+	}
+
+	repositorytest.Run(t, func(t *testing.T) repository.FollowersRepository {
+		t.Helper()
+		ctx := context.Background()
+
+		// Keep the recorded migrations, so the schema is only set up once
+		_, err := neo4j.ExecuteQuery(ctx, driver, "MATCH (n) WHERE NOT n:SchemaMigration DETACH DELETE n", nil, neo4j.EagerResultTransformer)
+		if err != nil {
+			t.Fatalf("wiping the graph: %v", err)
+		}
+
+		repo := repository.NewNeo4jFollowersRepository(driver)
+		if _, err := repo.Migrate(ctx); err != nil {
+			t.Fatalf("Migrate: %v", err)
+		}
+		return repo
+	})
+}
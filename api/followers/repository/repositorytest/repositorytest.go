@@ -0,0 +1,451 @@
+// Package repositorytest holds the contract every repository.FollowersRepository backend must pass.
+//
+// A backend runs it from its own tests, with a constructor returning an empty repository:
+//
+//	func TestMemoryFollowersRepository(t *testing.T) {
+//		repositorytest.Run(t, func(t *testing.T) repository.FollowersRepository {
+//			return repository.NewMemoryFollowersRepository()
+//		})
+//	}
+package repositorytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"hornet/api/followers/model"
+	"hornet/api/followers/repository"
+	"hornet/common/pagination"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Run checks the behavior of the repositories returned by newRepository, one empty repository per subtest.
+func Run(t *testing.T, newRepository func(t *testing.T) repository.FollowersRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo repository.FollowersRepository)
+	}{
+		{"CreateFollow", testCreateFollow},
+		{"DeleteFollow", testDeleteFollow},
+		{"FollowsPages", testFollowsPages},
+		{"Privacy", testPrivacy},
+		{"FollowRequests", testFollowRequests},
+		{"Blocks", testBlocks},
+		{"Mutes", testMutes},
+		{"Relationships", testRelationships},
+		{"MutualsAndKnownFollowers", testMutualsAndKnownFollowers},
+		{"Suggestions", testSuggestions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepository(t))
+		})
+	}
+}
+
+// baseTime is a creation time every backend stores without losing precision.
+var baseTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// at returns the time n seconds after baseTime.
+func at(n int) time.Time {
+	return baseTime.Add(time.Duration(n) * time.Second)
+}
+
+// follow makes sender follow receiver at the given time.
+func follow(t *testing.T, repo repository.FollowersRepository, senderID, receiverID uuid.UUID, createdAt time.Time) model.Follow {
+	t.Helper()
+	saved, _, err := repo.CreateFollow(context.Background(), &model.Follow{
+		ID:         uuid.New(),
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		CreatedAt:  createdAt,
+	})
+	if err != nil {
+		t.Fatalf("CreateFollow: %v", err)
+	}
+	return *saved
+}
+
+// sortedIDs returns the users sorted by ID, the order of lists of users.
+func sortedIDs(userIDs ...uuid.UUID) []uuid.UUID {
+	sorted := append([]uuid.UUID{}, userIDs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	return sorted
+}
+
+func expectUsers(t *testing.T, what string, got []uuid.UUID, want ...uuid.UUID) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d users, want %d", what, len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s: user %d is %s, want %s", what, i, got[i], want[i])
+		}
+	}
+}
+
+func testCreateFollow(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+
+	first := &model.Follow{ID: uuid.New(), SenderID: alice, ReceiverID: bob, CreatedAt: at(0)}
+	saved, created, err := repo.CreateFollow(ctx, first)
+	if err != nil || !created || saved.ID != first.ID {
+		t.Fatalf("CreateFollow: got %+v, %v, %v", saved, created, err)
+	}
+
+	second := &model.Follow{ID: uuid.New(), SenderID: alice, ReceiverID: bob, CreatedAt: at(1)}
+	saved, created, err = repo.CreateFollow(ctx, second)
+	if err != nil || created || saved.ID != first.ID || !saved.CreatedAt.Equal(at(0)) {
+		t.Fatalf("CreateFollow of an existing follow: got %+v, %v, %v, want the first follow", saved, created, err)
+	}
+
+	found, err := repo.GetFollowByID(ctx, first.ID)
+	if err != nil || found.SenderID != alice || found.ReceiverID != bob {
+		t.Fatalf("GetFollowByID: got %+v, %v", found, err)
+	}
+	if _, err := repo.GetFollowBetween(ctx, bob, alice); !errors.Is(err, repository.ErrFollowNotFound) {
+		t.Fatalf("GetFollowBetween in the other direction: got %v, want ErrFollowNotFound", err)
+	}
+
+	followers, err := repo.GetFollowersCount(ctx, bob)
+	if err != nil || followers != 1 {
+		t.Fatalf("GetFollowersCount: got %d, %v, want 1", followers, err)
+	}
+	following, err := repo.GetFollowingCount(ctx, alice)
+	if err != nil || following != 1 {
+		t.Fatalf("GetFollowingCount: got %d, %v, want 1", following, err)
+	}
+}
+
+func testDeleteFollow(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+
+	byID := follow(t, repo, alice, bob, at(0))
+	follow(t, repo, alice, carol, at(1))
+
+	if err := repo.DeleteFollow(ctx, byID.ID); err != nil {
+		t.Fatalf("DeleteFollow: %v", err)
+	}
+	if _, err := repo.GetFollowByID(ctx, byID.ID); !errors.Is(err, repository.ErrFollowNotFound) {
+		t.Fatalf("GetFollowByID of a deleted follow: got %v, want ErrFollowNotFound", err)
+	}
+
+	if deleted, err := repo.DeleteFollowBetween(ctx, alice, carol); err != nil || !deleted {
+		t.Fatalf("DeleteFollowBetween: got %v, %v", deleted, err)
+	}
+	if deleted, err := repo.DeleteFollowBetween(ctx, alice, carol); err != nil || deleted {
+		t.Fatalf("DeleteFollowBetween of a deleted follow: got %v, %v", deleted, err)
+	}
+}
+
+func testFollowsPages(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	celebrity := uuid.New()
+
+	var follows []model.Follow
+	for i := 0; i < 5; i++ {
+		follows = append(follows, follow(t, repo, uuid.New(), celebrity, at(i)))
+	}
+
+	page, hasMore, err := repo.GetFollowers(ctx, celebrity, pagination.Request{Limit: 2})
+	if err != nil || len(page) != 2 || !hasMore || page[0].ID != follows[4].ID || page[1].ID != follows[3].ID {
+		t.Fatalf("GetFollowers first page: got %+v, %v, %v, want the 2 newest and more", page, hasMore, err)
+	}
+
+	after := &pagination.Cursor{CreatedAt: page[1].CreatedAt, ID: page[1].ID}
+	page, hasMore, err = repo.GetFollowers(ctx, celebrity, pagination.Request{Limit: 3, After: after})
+	if err != nil || len(page) != 3 || hasMore || page[0].ID != follows[2].ID || page[2].ID != follows[0].ID {
+		t.Fatalf("GetFollowers last page: got %+v, %v, %v, want the 3 oldest", page, hasMore, err)
+	}
+
+	before := &pagination.Cursor{CreatedAt: follows[1].CreatedAt, ID: follows[1].ID}
+	page, hasMore, err = repo.GetFollowers(ctx, celebrity, pagination.Request{Limit: 2, Before: before})
+	if err != nil || len(page) != 2 || !hasMore || page[0].ID != follows[3].ID || page[1].ID != follows[2].ID {
+		t.Fatalf("GetFollowers backward page: got %+v, %v, %v, want the 2 follows before the cursor, newest first", page, hasMore, err)
+	}
+
+	following, _, err := repo.GetFollowing(ctx, follows[0].SenderID, pagination.Request{Limit: 10})
+	if err != nil || len(following) != 1 || following[0].ReceiverID != celebrity {
+		t.Fatalf("GetFollowing: got %+v, %v", following, err)
+	}
+}
+
+func testPrivacy(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	if private, err := repo.IsPrivate(ctx, userID); err != nil || private {
+		t.Fatalf("IsPrivate of an unknown user: got %v, %v, want public", private, err)
+	}
+	if err := repo.SetPrivate(ctx, userID, true); err != nil {
+		t.Fatalf("SetPrivate: %v", err)
+	}
+	if private, err := repo.IsPrivate(ctx, userID); err != nil || !private {
+		t.Fatalf("IsPrivate after SetPrivate: got %v, %v", private, err)
+	}
+}
+
+func testFollowRequests(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+
+	request := &model.FollowRequest{ID: uuid.New(), SenderID: alice, ReceiverID: bob, CreatedAt: at(0)}
+	saved, created, err := repo.CreateFollowRequest(ctx, request)
+	if err != nil || !created || saved.ID != request.ID {
+		t.Fatalf("CreateFollowRequest: got %+v, %v, %v", saved, created, err)
+	}
+	repeated := &model.FollowRequest{ID: uuid.New(), SenderID: alice, ReceiverID: bob, CreatedAt: at(1)}
+	if saved, created, err := repo.CreateFollowRequest(ctx, repeated); err != nil || created || saved.ID != request.ID {
+		t.Fatalf("CreateFollowRequest of a pending request: got %+v, %v, %v, want the first request", saved, created, err)
+	}
+
+	incoming, _, err := repo.GetIncomingFollowRequests(ctx, bob, pagination.Request{Limit: 10})
+	if err != nil || len(incoming) != 1 || incoming[0].ID != request.ID {
+		t.Fatalf("GetIncomingFollowRequests: got %+v, %v", incoming, err)
+	}
+	outgoing, _, err := repo.GetOutgoingFollowRequests(ctx, alice, pagination.Request{Limit: 10})
+	if err != nil || len(outgoing) != 1 || outgoing[0].ID != request.ID {
+		t.Fatalf("GetOutgoingFollowRequests: got %+v, %v", outgoing, err)
+	}
+
+	followID := uuid.New()
+	approved, err := repo.ApproveFollowRequest(ctx, request.ID, followID, at(2))
+	if err != nil || approved.ID != followID || approved.SenderID != alice || !approved.CreatedAt.Equal(at(2)) {
+		t.Fatalf("ApproveFollowRequest: got %+v, %v", approved, err)
+	}
+	if _, err := repo.GetFollowRequestByID(ctx, request.ID); !errors.Is(err, repository.ErrFollowRequestNotFound) {
+		t.Fatalf("GetFollowRequestByID of an approved request: got %v, want ErrFollowRequestNotFound", err)
+	}
+	if _, err := repo.ApproveFollowRequest(ctx, request.ID, uuid.New(), at(3)); !errors.Is(err, repository.ErrFollowRequestNotFound) {
+		t.Fatalf("ApproveFollowRequest of an approved request: got %v, want ErrFollowRequestNotFound", err)
+	}
+
+	// A follow replaces the pending request between the same users
+	pending := &model.FollowRequest{ID: uuid.New(), SenderID: carol, ReceiverID: bob, CreatedAt: at(4)}
+	if _, _, err := repo.CreateFollowRequest(ctx, pending); err != nil {
+		t.Fatalf("CreateFollowRequest: %v", err)
+	}
+	follow(t, repo, carol, bob, at(5))
+	if _, err := repo.GetFollowRequestByID(ctx, pending.ID); !errors.Is(err, repository.ErrFollowRequestNotFound) {
+		t.Fatalf("GetFollowRequestByID after a follow: got %v, want ErrFollowRequestNotFound", err)
+	}
+
+	cancelled := &model.FollowRequest{ID: uuid.New(), SenderID: bob, ReceiverID: carol, CreatedAt: at(6)}
+	if _, _, err := repo.CreateFollowRequest(ctx, cancelled); err != nil {
+		t.Fatalf("CreateFollowRequest: %v", err)
+	}
+	if deleted, err := repo.DeleteFollowRequest(ctx, cancelled.ID); err != nil || !deleted {
+		t.Fatalf("DeleteFollowRequest: got %v, %v", deleted, err)
+	}
+	if deleted, err := repo.DeleteFollowRequest(ctx, cancelled.ID); err != nil || deleted {
+		t.Fatalf("DeleteFollowRequest of a deleted request: got %v, %v", deleted, err)
+	}
+}
+
+func testBlocks(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+
+	follow(t, repo, alice, bob, at(0))
+	follow(t, repo, bob, alice, at(1))
+
+	block := &model.Block{BlockerID: alice, BlockedID: bob, CreatedAt: at(2)}
+	saved, created, err := repo.CreateBlock(ctx, block)
+	if err != nil || !created || !saved.CreatedAt.Equal(at(2)) {
+		t.Fatalf("CreateBlock: got %+v, %v, %v", saved, created, err)
+	}
+	if saved, created, err := repo.CreateBlock(ctx, &model.Block{BlockerID: alice, BlockedID: bob, CreatedAt: at(3)}); err != nil || created || !saved.CreatedAt.Equal(at(2)) {
+		t.Fatalf("CreateBlock of an existing block: got %+v, %v, %v, want the first block", saved, created, err)
+	}
+
+	for _, pair := range [][2]uuid.UUID{{alice, bob}, {bob, alice}} {
+		if _, err := repo.GetFollowBetween(ctx, pair[0], pair[1]); !errors.Is(err, repository.ErrFollowNotFound) {
+			t.Fatalf("GetFollowBetween across a block: got %v, want ErrFollowNotFound", err)
+		}
+	}
+
+	// Neither user can follow or ask to follow the other while the block stands
+	for _, pair := range [][2]uuid.UUID{{alice, bob}, {bob, alice}} {
+		_, _, err := repo.CreateFollow(ctx, &model.Follow{ID: uuid.New(), SenderID: pair[0], ReceiverID: pair[1], CreatedAt: at(4)})
+		if !errors.Is(err, repository.ErrBlocked) {
+			t.Fatalf("CreateFollow across a block: got %v, want ErrBlocked", err)
+		}
+		_, _, err = repo.CreateFollowRequest(ctx, &model.FollowRequest{ID: uuid.New(), SenderID: pair[0], ReceiverID: pair[1], CreatedAt: at(4)})
+		if !errors.Is(err, repository.ErrBlocked) {
+			t.Fatalf("CreateFollowRequest across a block: got %v, want ErrBlocked", err)
+		}
+	}
+
+	if blocked, err := repo.IsBlocked(ctx, alice, bob); err != nil || !blocked {
+		t.Fatalf("IsBlocked: got %v, %v", blocked, err)
+	}
+	if blocked, err := repo.IsBlocked(ctx, bob, alice); err != nil || blocked {
+		t.Fatalf("IsBlocked in the other direction: got %v, %v", blocked, err)
+	}
+
+	if deleted, err := repo.DeleteBlock(ctx, alice, bob); err != nil || !deleted {
+		t.Fatalf("DeleteBlock: got %v, %v", deleted, err)
+	}
+	if deleted, err := repo.DeleteBlock(ctx, alice, bob); err != nil || deleted {
+		t.Fatalf("DeleteBlock of a deleted block: got %v, %v", deleted, err)
+	}
+
+	if _, created, err := repo.CreateFollow(ctx, &model.Follow{ID: uuid.New(), SenderID: bob, ReceiverID: alice, CreatedAt: at(5)}); err != nil || !created {
+		t.Fatalf("CreateFollow after an unblock: got %v, %v", created, err)
+	}
+}
+
+func testMutes(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	userID, muted, conversation, lapsed := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	expiresAt := at(10)
+	expiredAt := at(1)
+
+	mutes := []*model.Mute{
+		{Kind: model.MuteKindUser, TargetID: muted, CreatedAt: at(0)},
+		{Kind: model.MuteKindConversation, TargetID: conversation, CreatedAt: at(1), ExpiresAt: &expiresAt},
+		{Kind: model.MuteKindUser, TargetID: lapsed, CreatedAt: at(0), ExpiresAt: &expiredAt},
+	}
+	for _, mute := range mutes {
+		if err := repo.SaveMute(ctx, userID, mute); err != nil {
+			t.Fatalf("SaveMute: %v", err)
+		}
+	}
+	if err := repo.SaveMute(ctx, userID, &model.Mute{Kind: "unknown", TargetID: uuid.New(), CreatedAt: at(0)}); err == nil {
+		t.Fatal("SaveMute of an unknown kind: got no error")
+	}
+
+	active, err := repo.GetMutes(ctx, userID, at(5))
+	if err != nil || len(active) != 2 || active[0].TargetID != conversation || active[1].TargetID != muted {
+		t.Fatalf("GetMutes: got %+v, %v, want the 2 active mutes, newest first", active, err)
+	}
+	if active[0].Kind != model.MuteKindConversation || active[0].ExpiresAt == nil || !active[0].ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("GetMutes: got %+v, want the conversation mute with its expiry", active[0])
+	}
+
+	if deleted, err := repo.DeleteMute(ctx, userID, model.MuteKindUser, lapsed, at(5)); err != nil || deleted {
+		t.Fatalf("DeleteMute of an expired mute: got %v, %v, want not active", deleted, err)
+	}
+	if deleted, err := repo.DeleteMute(ctx, userID, model.MuteKindConversation, muted, at(5)); err != nil || deleted {
+		t.Fatalf("DeleteMute of another kind: got %v, %v", deleted, err)
+	}
+	if deleted, err := repo.DeleteMute(ctx, userID, model.MuteKindUser, muted, at(5)); err != nil || !deleted {
+		t.Fatalf("DeleteMute: got %v, %v", deleted, err)
+	}
+}
+
+func testRelationships(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	me, followed, follower, requested, blocked, blocker := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	follow(t, repo, me, followed, at(0))
+	follow(t, repo, follower, me, at(1))
+	if _, _, err := repo.CreateFollowRequest(ctx, &model.FollowRequest{ID: uuid.New(), SenderID: me, ReceiverID: requested, CreatedAt: at(2)}); err != nil {
+		t.Fatalf("CreateFollowRequest: %v", err)
+	}
+	for _, block := range []*model.Block{{BlockerID: me, BlockedID: blocked, CreatedAt: at(3)}, {BlockerID: blocker, BlockedID: me, CreatedAt: at(4)}} {
+		if _, _, err := repo.CreateBlock(ctx, block); err != nil {
+			t.Fatalf("CreateBlock: %v", err)
+		}
+	}
+
+	stranger := uuid.New()
+	relationships, err := repo.GetRelationships(ctx, me, []uuid.UUID{followed, follower, requested, blocked, blocker, stranger})
+	if err != nil || len(relationships) != 6 {
+		t.Fatalf("GetRelationships: got %d relationships, %v, want 6", len(relationships), err)
+	}
+
+	want := []model.Relationship{
+		{UserID: followed, Following: true},
+		{UserID: follower, FollowedBy: true},
+		{UserID: requested, Requested: true},
+		{UserID: blocked, Blocking: true},
+		{UserID: blocker, BlockedBy: true},
+		{UserID: stranger},
+	}
+	for i := range want {
+		if relationships[i] != want[i] {
+			t.Fatalf("GetRelationships: relationship %d is %+v, want %+v", i, relationships[i], want[i])
+		}
+	}
+}
+
+func testMutualsAndKnownFollowers(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	me, viewer := uuid.New(), uuid.New()
+	friends := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+
+	for _, friend := range friends {
+		follow(t, repo, me, friend, at(0))
+		follow(t, repo, friend, me, at(1))
+	}
+	follow(t, repo, uuid.New(), me, at(2)) // A follower who is not followed back
+	follow(t, repo, viewer, friends[0], at(3))
+	follow(t, repo, viewer, friends[2], at(3))
+
+	sorted := sortedIDs(friends...)
+	mutuals, count, hasMore, err := repo.GetMutuals(ctx, me, pagination.Request{Limit: 2})
+	if err != nil || count == nil || *count != 3 || !hasMore {
+		t.Fatalf("GetMutuals: got count %v, hasMore %v, %v, want 3 and more", count, hasMore, err)
+	}
+	expectUsers(t, "GetMutuals first page", mutuals, sorted[0], sorted[1])
+
+	// Later pages are not counted again
+	mutuals, count, hasMore, err = repo.GetMutuals(ctx, me, pagination.Request{Limit: 2, After: &pagination.Cursor{ID: sorted[1]}})
+	if err != nil || count != nil || hasMore {
+		t.Fatalf("GetMutuals last page: got count %v, hasMore %v, %v, want no count", count, hasMore, err)
+	}
+	expectUsers(t, "GetMutuals last page", mutuals, sorted[2])
+
+	known, count, _, err := repo.GetKnownFollowers(ctx, viewer, me, pagination.Request{Limit: 10})
+	if err != nil || count == nil || *count != 2 {
+		t.Fatalf("GetKnownFollowers: got count %v, %v, want 2", count, err)
+	}
+	expectUsers(t, "GetKnownFollowers", known, sortedIDs(friends[0], friends[2])...)
+}
+
+func testSuggestions(t *testing.T, repo repository.FollowersRepository) {
+	ctx := context.Background()
+	me := uuid.New()
+	followees := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	popular, known, blocked, alreadyFollowed := uuid.New(), uuid.New(), uuid.New(), followees[1]
+
+	for _, followee := range followees {
+		follow(t, repo, me, followee, at(0))
+		follow(t, repo, followee, popular, at(1))
+		follow(t, repo, followee, blocked, at(1))
+	}
+	follow(t, repo, followees[0], known, at(2))
+	follow(t, repo, followees[0], alreadyFollowed, at(2))
+	follow(t, repo, followees[0], me, at(2))
+	if _, _, err := repo.CreateBlock(ctx, &model.Block{BlockerID: blocked, BlockedID: me, CreatedAt: at(3)}); err != nil {
+		t.Fatalf("CreateBlock: %v", err)
+	}
+
+	suggestions, hasMore, err := repo.GetSuggestions(ctx, me, pagination.Request{Limit: 1})
+	if err != nil || len(suggestions) != 1 || !hasMore {
+		t.Fatalf("GetSuggestions first page: got %+v, %v, %v, want 1 suggestion and more", suggestions, hasMore, err)
+	}
+	if suggestions[0].UserID != popular || suggestions[0].MutualCount != 3 || len(suggestions[0].FollowedBy) != 3 {
+		t.Fatalf("GetSuggestions first page: got %+v, want the user followed by every followee", suggestions[0])
+	}
+
+	after := &pagination.Cursor{Score: suggestions[0].MutualCount, ID: suggestions[0].UserID}
+	suggestions, hasMore, err = repo.GetSuggestions(ctx, me, pagination.Request{Limit: 10, After: after})
+	if err != nil || len(suggestions) != 1 || hasMore {
+		t.Fatalf("GetSuggestions last page: got %+v, %v, %v, want 1 suggestion", suggestions, hasMore, err)
+	}
+	if suggestions[0].UserID != known || suggestions[0].MutualCount != 1 {
+		t.Fatalf("GetSuggestions last page: got %+v, want the user followed by one followee", suggestions[0])
+	}
+}
@@ -2,27 +2,85 @@ package followers
 
 import (
 	"hornet/api/followers/handler"
+	"hornet/api/followers/model"
 	"hornet/api/followers/service"
+	"hornet/common/auth"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Router sets up the Gin router with all the routes
-func Router(followersService *service.FollowersService) *gin.Engine {
+func Router(followersService *service.FollowersService, authenticator *auth.Authenticator) *gin.Engine {
 	r := gin.Default()
 
+	// Put the authenticated user, if any, into the context of every request
+	r.Use(authenticator.Middleware())
+
 	// Create a new follow
 	r.POST("/followers", handler.CreateFollow(followersService))
 
 	// Delete a follow by ID
 	r.DELETE("/followers/:follow_id", handler.DeleteFollow(followersService))
 
+	// Block a user, severing the follows between both users
+	r.POST("/followers/blocks/:user_id", handler.Block(followersService))
+
+	// Unblock a user
+	r.DELETE("/followers/blocks/:user_id", handler.Unblock(followersService))
+
+	// Check whether a user blocks another, for other services and the users involved
+	r.GET("/followers/blocks/check", handler.CheckBlock(followersService))
+
+	// Get the active mutes of the user
+	r.GET("/followers/mutes", handler.GetMutes(followersService))
+
+	// Mute or unmute a user, optionally until an expiry
+	r.PUT("/followers/mutes/users/:user_id", handler.Mute(followersService, model.MuteKindUser, "user_id"))
+	r.DELETE("/followers/mutes/users/:user_id", handler.Unmute(followersService, model.MuteKindUser, "user_id"))
+
+	// Mute or unmute a conversation by its root post, optionally until an expiry
+	r.PUT("/followers/mutes/conversations/:post_id", handler.Mute(followersService, model.MuteKindConversation, "post_id"))
+	r.DELETE("/followers/mutes/conversations/:post_id", handler.Unmute(followersService, model.MuteKindConversation, "post_id"))
+
+	// Set whether following the user requires their approval
+	r.PUT("/followers/privacy", handler.SetPrivacy(followersService))
+
+	// Get the follow requests sent to or by the user
+	r.GET("/followers/requests", handler.GetFollowRequests(followersService))
+
+	// Approve a follow request sent to the user
+	r.POST("/followers/requests/:request_id/approve", handler.ApproveFollowRequest(followersService))
+
+	// Reject a follow request sent to the user
+	r.POST("/followers/requests/:request_id/reject", handler.RejectFollowRequest(followersService))
+
+	// Cancel a follow request sent by the user
+	r.DELETE("/followers/requests/:request_id", handler.CancelFollowRequest(followersService))
+
+	// Unfollow a user by their ID
+	r.DELETE("/followers/user/:user_id", handler.Unfollow(followersService))
+
+	// Get whether the user and another user follow each other
+	r.GET("/followers/relationship/:other_id", handler.GetRelationship(followersService))
+
+	// Get the relationships with a batch of users
+	r.GET("/followers/relationships", handler.GetRelationships(followersService))
+
+	// Get who-to-follow suggestions for the user
+	r.GET("/followers/suggestions", handler.GetSuggestions(followersService))
+
 	// Get user followers
 	r.GET("/followers/user/:user_id/followers", handler.GetUserFollowers(followersService))
 
 	// Get user following
 	r.GET("/followers/user/:user_id/following", handler.GetUserFollowing(followersService))
 
+	// Get the users who follow the user and are followed back
+	r.GET("/followers/user/:user_id/mutuals", handler.GetMutuals(followersService))
+
+	// Get the user's followers that the requesting user follows
+	r.GET("/followers/user/:user_id/known-followers", handler.GetKnownFollowers(followersService))
+
 	// Get user followers count
 	r.GET("/followers/user/:user_id/followers/count", handler.GetFollowersCount(followersService))
 
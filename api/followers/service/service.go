@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"hornet/api/followers/model"
 	"hornet/api/followers/repository"
-	"sync"
+	"hornet/common/pagination"
+	"hornet/common/problem"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,32 +15,79 @@ import (
 
 // FollowersService defines the methods for handling followers-related business logic
 type FollowersService struct {
-	followersRepository *repository.FollowersRepository
+	followersRepository repository.FollowersRepository
 }
 
 var (
-	followersServiceInstance *FollowersService
-	followersOnce            sync.Once
+	// ErrFollowNotFound is returned when the follow relationship does not exist
+	ErrFollowNotFound = problem.NotFound("follow_not_found", "Follow not found")
+
+	// ErrFollowRequestNotFound is returned when the follow request does not exist
+	ErrFollowRequestNotFound = problem.NotFound("follow_request_not_found", "Follow request not found")
+
+	// ErrInvalidDirection is returned when a list of follow requests is neither incoming nor outgoing
+	ErrInvalidDirection = problem.BadRequest("invalid_direction", fmt.Sprintf("direction must be %q or %q", model.RequestsIncoming, model.RequestsOutgoing))
+
+	// ErrNilID is returned when a user or relationship ID is the nil UUID
+	ErrNilID = problem.BadRequest("nil_id", "IDs cannot be nil")
+
+	// ErrSelf is returned when a user would follow, block or mute themselves
+	ErrSelf = problem.Validation("self_relationship", "user_id", "Users cannot follow, block or mute themselves")
+
+	// ErrForbidden is returned when the user is not allowed to act on the relationship
+	ErrForbidden = problem.Forbidden("forbidden", "You are not allowed to act on this relationship")
+
+	// ErrBlocked is returned when following a user across a block, in either direction
+	ErrBlocked = problem.Forbidden("blocked", "You cannot follow this user")
+
+	// ErrBlockNotFound is returned when the user does not block the other user
+	ErrBlockNotFound = problem.NotFound("block_not_found", "Block not found")
+
+	// ErrMuteNotFound is returned when the user has no active mute of the target
+	ErrMuteNotFound = problem.NotFound("mute_not_found", "Mute not found")
+
+	// ErrInvalidExpiry is returned when a mute would expire before it is created
+	ErrInvalidExpiry = problem.Validation("invalid_expiry", "expires_at", "expires_at must be in the future")
+
+	// ErrBackwardNotSupported is returned when paging backward through a ranked list
+	ErrBackwardNotSupported = problem.BadRequest("backward_not_supported", "before is not supported for this list")
+
+	// ErrTooManyUsers is returned when a batch lookup exceeds MaxBatchSize
+	ErrTooManyUsers = problem.BadRequest("too_many_users", fmt.Sprintf("at most %d users can be looked up at once", MaxBatchSize))
 )
 
-// NewFollowersService creates a singleton instance of FollowersService.
-func NewFollowersService(follRepository *repository.FollowersRepository) *FollowersService {
+// MaxBatchSize is the largest number of users a batch lookup accepts.
+const MaxBatchSize = 100
+
+// NewFollowersService creates a FollowersService backed by the given repository.
+func NewFollowersService(follRepository repository.FollowersRepository) *FollowersService {
 	if follRepository == nil {
 		panic("repository cannot be nil")
 	}
 
-	followersOnce.Do(func() {
-		followersServiceInstance = &FollowersService{
-			followersRepository: follRepository,
-		}
-	})
-	return followersServiceInstance
+	return &FollowersService{
+		followersRepository: follRepository,
+	}
 }
 
-// CreateFollow creates a new follow relationship.
-func (s *FollowersService) CreateFollow(ctx context.Context, senderID, receiverID uuid.UUID) (*model.Follow, error) {
+// CreateFollow follows the receiver on behalf of the sender. Public accounts are followed
+// right away, private ones receive a follow request to approve, unless the sender already
+// follows them. The boolean reports whether the follow or the request was created.
+func (s *FollowersService) CreateFollow(ctx context.Context, senderID, receiverID uuid.UUID) (model.FollowResult, bool, error) {
+	if senderID == uuid.Nil || receiverID == uuid.Nil {
+		return model.FollowResult{}, false, ErrNilID
+	}
 	if senderID == receiverID {
-		return nil, fmt.Errorf("sender and receiver IDs cannot be the same")
+		return model.FollowResult{}, false, ErrSelf
+	}
+
+	private, err := s.followersRepository.IsPrivate(ctx, receiverID)
+	if err != nil {
+		return model.FollowResult{}, false, fmt.Errorf("failed to get privacy of user with ID %s: %w", receiverID, err)
+	}
+
+	if private {
+		return s.requestFollow(ctx, senderID, receiverID)
 	}
 
 	follow := &model.Follow{
@@ -48,21 +97,327 @@ func (s *FollowersService) CreateFollow(ctx context.Context, senderID, receiverI
 		CreatedAt:  time.Now().UTC(),
 	}
 
-	savedFollow, err := s.followersRepository.CreateFollow(ctx, follow)
+	// The block check runs in the same transaction as the write, so a concurrent block cannot slip in between
+	savedFollow, created, err := s.followersRepository.CreateFollow(ctx, follow)
+	if errors.Is(err, repository.ErrBlocked) {
+		return model.FollowResult{}, false, ErrBlocked
+	}
+	if err != nil {
+		return model.FollowResult{}, false, fmt.Errorf("failed to create follow: %w", err)
+	}
+
+	return model.FollowResult{Follow: savedFollow}, created, nil
+}
+
+// requestFollow sends a follow request to a private account, or returns the existing follow.
+func (s *FollowersService) requestFollow(ctx context.Context, senderID, receiverID uuid.UUID) (model.FollowResult, bool, error) {
+	follow, err := s.followersRepository.GetFollowBetween(ctx, senderID, receiverID)
+	if err == nil {
+		return model.FollowResult{Follow: follow}, false, nil
+	}
+	if !errors.Is(err, repository.ErrFollowNotFound) {
+		return model.FollowResult{}, false, fmt.Errorf("failed to get follow: %w", err)
+	}
+
+	request := &model.FollowRequest{
+		ID:         uuid.New(),
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	savedRequest, created, err := s.followersRepository.CreateFollowRequest(ctx, request)
+	if errors.Is(err, repository.ErrBlocked) {
+		return model.FollowResult{}, false, ErrBlocked
+	}
+	if err != nil {
+		return model.FollowResult{}, false, fmt.Errorf("failed to create follow request: %w", err)
+	}
+
+	return model.FollowResult{Request: savedRequest}, created, nil
+}
+
+// Block blocks a user on behalf of the blocker, severing the follows and follow requests
+// between them. The boolean reports whether the block was created.
+func (s *FollowersService) Block(ctx context.Context, blockerID, blockedID uuid.UUID) (*model.Block, bool, error) {
+	if blockerID == blockedID {
+		return nil, false, ErrSelf
+	}
+
+	block := &model.Block{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	savedBlock, created, err := s.followersRepository.CreateBlock(ctx, block)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to block user with ID %s: %w", blockedID, err)
+	}
+
+	return savedBlock, created, nil
+}
+
+// Unblock deletes the block from a blocker to a blocked user. Severed follows are not restored.
+func (s *FollowersService) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	if blockerID == uuid.Nil || blockedID == uuid.Nil {
+		return ErrNilID
+	}
+
+	deleted, err := s.followersRepository.DeleteBlock(ctx, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock user with ID %s: %w", blockedID, err)
+	}
+	if !deleted {
+		return ErrBlockNotFound
+	}
+
+	return nil
+}
+
+// IsBlocked reports whether a blocker blocks a blocked user.
+func (s *FollowersService) IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (model.BlockStatus, error) {
+	if blockerID == uuid.Nil || blockedID == uuid.Nil {
+		return model.BlockStatus{}, ErrNilID
+	}
+
+	blocked, err := s.followersRepository.IsBlocked(ctx, blockerID, blockedID)
+	if err != nil {
+		return model.BlockStatus{}, fmt.Errorf("failed to check block of user with ID %s: %w", blockedID, err)
+	}
+
+	return model.BlockStatus{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+		Blocked:   blocked,
+	}, nil
+}
+
+// Mute mutes a user, or a conversation by its root post, on behalf of a user until expiresAt,
+// or until unmuted if expiresAt is nil. Muting again replaces the expiry.
+func (s *FollowersService) Mute(ctx context.Context, userID uuid.UUID, kind string, targetID uuid.UUID, expiresAt *time.Time) (*model.Mute, error) {
+	if userID == uuid.Nil || targetID == uuid.Nil {
+		return nil, ErrNilID
+	}
+	if kind == model.MuteKindUser && userID == targetID {
+		return nil, ErrSelf
+	}
+
+	now := time.Now().UTC()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, ErrInvalidExpiry
+	}
+
+	mute := &model.Mute{
+		Kind:      kind,
+		TargetID:  targetID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := s.followersRepository.SaveMute(ctx, userID, mute); err != nil {
+		return nil, fmt.Errorf("failed to mute %s with ID %s: %w", kind, targetID, err)
+	}
+
+	return mute, nil
+}
+
+// Unmute deletes the active mute of a user, or of a conversation by its root post.
+func (s *FollowersService) Unmute(ctx context.Context, userID uuid.UUID, kind string, targetID uuid.UUID) error {
+	if userID == uuid.Nil || targetID == uuid.Nil {
+		return ErrNilID
+	}
+
+	deleted, err := s.followersRepository.DeleteMute(ctx, userID, kind, targetID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to unmute %s with ID %s: %w", kind, targetID, err)
+	}
+	if !deleted {
+		return ErrMuteNotFound
+	}
+
+	return nil
+}
+
+// GetMutes retrieves the active mutes of a specific user, grouped by kind.
+func (s *FollowersService) GetMutes(ctx context.Context, userID uuid.UUID) (model.Mutes, error) {
+	if userID == uuid.Nil {
+		return model.Mutes{}, ErrNilID
+	}
+
+	mutes, err := s.followersRepository.GetMutes(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return model.Mutes{}, fmt.Errorf("failed to get mutes for user with ID %s: %w", userID, err)
+	}
+
+	grouped := model.Mutes{
+		Users:         []model.Mute{},
+		Conversations: []model.Mute{},
+	}
+	for _, mute := range mutes {
+		if mute.Kind == model.MuteKindConversation {
+			grouped.Conversations = append(grouped.Conversations, mute)
+		} else {
+			grouped.Users = append(grouped.Users, mute)
+		}
+	}
+
+	return grouped, nil
+}
+
+// SetPrivate sets whether following a user requires their approval.
+// Pending requests are kept when an account becomes public, and can still be approved.
+func (s *FollowersService) SetPrivate(ctx context.Context, userID uuid.UUID, private bool) error {
+	if userID == uuid.Nil {
+		return ErrNilID
+	}
+
+	if err := s.followersRepository.SetPrivate(ctx, userID, private); err != nil {
+		return fmt.Errorf("failed to set privacy of user with ID %s: %w", userID, err)
+	}
+
+	return nil
+}
+
+// GetFollowRequests retrieves one page of the follow requests sent to, or by, a specific user.
+func (s *FollowersService) GetFollowRequests(ctx context.Context, userID uuid.UUID, direction string, page pagination.Request) (model.FollowRequestsPage, error) {
+	if userID == uuid.Nil {
+		return model.FollowRequestsPage{}, ErrNilID
+	}
+
+	var requests []model.FollowRequest
+	var hasMore bool
+	var err error
+	switch direction {
+	case model.RequestsIncoming:
+		requests, hasMore, err = s.followersRepository.GetIncomingFollowRequests(ctx, userID, page)
+	case model.RequestsOutgoing:
+		requests, hasMore, err = s.followersRepository.GetOutgoingFollowRequests(ctx, userID, page)
+	default:
+		return model.FollowRequestsPage{}, ErrInvalidDirection
+	}
+	if err != nil {
+		return model.FollowRequestsPage{}, fmt.Errorf("failed to get %s follow requests for user with ID %s: %w", direction, userID, err)
+	}
+
+	next, prev := page.Cursors(len(requests), hasMore, func(i int) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: requests[i].CreatedAt, ID: requests[i].ID}
+	})
+
+	return model.FollowRequestsPage{
+		Requests:   requests,
+		NextCursor: next,
+		PrevCursor: prev,
+	}, nil
+}
+
+// ApproveFollowRequest turns a follow request into a follow, dated from the approval.
+// Only the receiver of the request may approve it.
+func (s *FollowersService) ApproveFollowRequest(ctx context.Context, userID, requestID uuid.UUID) (*model.Follow, error) {
+	if _, err := s.getOwnFollowRequest(ctx, userID, requestID, true); err != nil {
+		return nil, err
+	}
+
+	follow, err := s.followersRepository.ApproveFollowRequest(ctx, requestID, uuid.New(), time.Now().UTC())
+	if errors.Is(err, repository.ErrFollowRequestNotFound) {
+		return nil, ErrFollowRequestNotFound
+	}
+	if errors.Is(err, repository.ErrBlocked) {
+		return nil, ErrBlocked
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create follow: %w", err)
+		return nil, fmt.Errorf("failed to approve follow request with ID %s: %w", requestID, err)
 	}
 
-	return savedFollow, nil
+	return follow, nil
 }
 
-// DeleteFollow deletes an existing follow relationship.
+// RejectFollowRequest deletes a follow request on behalf of its receiver.
+func (s *FollowersService) RejectFollowRequest(ctx context.Context, userID, requestID uuid.UUID) error {
+	if _, err := s.getOwnFollowRequest(ctx, userID, requestID, true); err != nil {
+		return err
+	}
+
+	return s.deleteFollowRequest(ctx, requestID)
+}
+
+// CancelFollowRequest deletes a follow request on behalf of its sender.
+func (s *FollowersService) CancelFollowRequest(ctx context.Context, userID, requestID uuid.UUID) error {
+	if _, err := s.getOwnFollowRequest(ctx, userID, requestID, false); err != nil {
+		return err
+	}
+
+	return s.deleteFollowRequest(ctx, requestID)
+}
+
+// getOwnFollowRequest retrieves a follow request the user received, or sent when received is false.
+func (s *FollowersService) getOwnFollowRequest(ctx context.Context, userID, requestID uuid.UUID, received bool) (*model.FollowRequest, error) {
+	if userID == uuid.Nil || requestID == uuid.Nil {
+		return nil, ErrNilID
+	}
+
+	request, err := s.followersRepository.GetFollowRequestByID(ctx, requestID)
+	if errors.Is(err, repository.ErrFollowRequestNotFound) {
+		return nil, ErrFollowRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow request with ID %s: %w", requestID, err)
+	}
+
+	owner := request.SenderID
+	if received {
+		owner = request.ReceiverID
+	}
+	if userID != owner {
+		return nil, ErrForbidden
+	}
+
+	return request, nil
+}
+
+// deleteFollowRequest deletes a follow request, which may have been approved or deleted meanwhile.
+func (s *FollowersService) deleteFollowRequest(ctx context.Context, requestID uuid.UUID) error {
+	deleted, err := s.followersRepository.DeleteFollowRequest(ctx, requestID)
+	if err != nil {
+		return fmt.Errorf("failed to delete follow request with ID %s: %w", requestID, err)
+	}
+	if !deleted {
+		return ErrFollowRequestNotFound
+	}
+
+	return nil
+}
+
+// RepairDuplicateFollows merges the duplicate follow relationships left by non-idempotent follows.
+func (s *FollowersService) RepairDuplicateFollows(ctx context.Context) (int, error) {
+	removed, err := s.followersRepository.RepairDuplicateFollows(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to repair duplicate follows: %w", err)
+	}
+
+	return removed, nil
+}
+
+// DeleteFollow deletes an existing follow relationship on behalf of a user.
+// Only its sender, unfollowing, or its receiver, removing a follower, may delete it.
 func (s *FollowersService) DeleteFollow(ctx context.Context, userID, followID uuid.UUID) error {
 	if userID == uuid.Nil || followID == uuid.Nil {
-		return fmt.Errorf("userID and followID cannot be nil")
+		return ErrNilID
+	}
+
+	follow, err := s.followersRepository.GetFollowByID(ctx, followID)
+	if errors.Is(err, repository.ErrFollowNotFound) {
+		return ErrFollowNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get follow with ID %s: %w", followID, err)
+	}
+
+	if userID != follow.SenderID && userID != follow.ReceiverID {
+		return ErrForbidden
 	}
 
-	err := s.followersRepository.DeleteFollow(ctx, followID)
+	err = s.followersRepository.DeleteFollow(ctx, followID)
 	if err != nil {
 		return fmt.Errorf("failed to delete follow with ID %s: %w", followID, err)
 	}
@@ -70,38 +425,183 @@ func (s *FollowersService) DeleteFollow(ctx context.Context, userID, followID uu
 	return nil
 }
 
-// GetFollowers retrieves a list of followers for a specific user.
-func (s *FollowersService) GetFollowers(ctx context.Context, userID uuid.UUID) ([]model.Follow, error) {
+// Unfollow deletes the follow relationship from a user to a target user.
+func (s *FollowersService) Unfollow(ctx context.Context, userID, targetID uuid.UUID) error {
+	if userID == uuid.Nil || targetID == uuid.Nil {
+		return ErrNilID
+	}
+
+	deleted, err := s.followersRepository.DeleteFollowBetween(ctx, userID, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow user with ID %s: %w", targetID, err)
+	}
+	if !deleted {
+		return ErrFollowNotFound
+	}
+
+	return nil
+}
+
+// GetRelationship retrieves whether a user and another user follow each other.
+func (s *FollowersService) GetRelationship(ctx context.Context, userID, otherID uuid.UUID) (model.Relationship, error) {
+	relationships, err := s.GetRelationships(ctx, userID, []uuid.UUID{otherID})
+	if err != nil {
+		return model.Relationship{}, err
+	}
+
+	return relationships[0], nil
+}
+
+// GetRelationships retrieves whether a user and each of the other users follow each other,
+// in the order of otherIDs.
+func (s *FollowersService) GetRelationships(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) ([]model.Relationship, error) {
+	if userID == uuid.Nil {
+		return nil, ErrNilID
+	}
+	if len(otherIDs) > MaxBatchSize {
+		return nil, ErrTooManyUsers
+	}
+
+	relationships, err := s.followersRepository.GetRelationships(ctx, userID, otherIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relationships for user with ID %s: %w", userID, err)
+	}
+
+	return relationships, nil
+}
+
+// GetFollowers retrieves one page of the followers of a specific user, newest first.
+func (s *FollowersService) GetFollowers(ctx context.Context, userID uuid.UUID, page pagination.Request) (model.FollowsPage, error) {
+	if userID == uuid.Nil {
+		return model.FollowsPage{}, ErrNilID
+	}
+
+	followers, hasMore, err := s.followersRepository.GetFollowers(ctx, userID, page)
+	if err != nil {
+		return model.FollowsPage{}, fmt.Errorf("failed to get followers for user with ID %s: %w", userID, err)
+	}
+
+	return newFollowsPage(followers, hasMore, page), nil
+}
+
+// GetFollowing retrieves one page of the users a specific user is following, newest first.
+func (s *FollowersService) GetFollowing(ctx context.Context, userID uuid.UUID, page pagination.Request) (model.FollowsPage, error) {
+	if userID == uuid.Nil {
+		return model.FollowsPage{}, ErrNilID
+	}
+
+	following, hasMore, err := s.followersRepository.GetFollowing(ctx, userID, page)
+	if err != nil {
+		return model.FollowsPage{}, fmt.Errorf("failed to get following for user with ID %s: %w", userID, err)
+	}
+
+	return newFollowsPage(following, hasMore, page), nil
+}
+
+// newFollowsPage wraps a page of follows with the cursors pointing at its neighbours.
+func newFollowsPage(follows []model.Follow, hasMore bool, page pagination.Request) model.FollowsPage {
+	next, prev := page.Cursors(len(follows), hasMore, func(i int) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: follows[i].CreatedAt, ID: follows[i].ID}
+	})
+
+	return model.FollowsPage{
+		Follows:    follows,
+		NextCursor: next,
+		PrevCursor: prev,
+	}
+}
+
+// GetMutuals retrieves one page of the users who follow a specific user and are followed back by them.
+func (s *FollowersService) GetMutuals(ctx context.Context, userID uuid.UUID, page pagination.Request) (model.UsersPage, error) {
 	if userID == uuid.Nil {
-		return nil, fmt.Errorf("userID cannot be nil")
+		return model.UsersPage{}, ErrNilID
+	}
+
+	userIDs, count, hasMore, err := s.followersRepository.GetMutuals(ctx, userID, page)
+	if err != nil {
+		return model.UsersPage{}, fmt.Errorf("failed to get mutuals for user with ID %s: %w", userID, err)
 	}
 
-	followers, err := s.followersRepository.GetFollowers(ctx, userID)
+	return newUsersPage(userIDs, count, hasMore, page), nil
+}
+
+// GetKnownFollowers retrieves one page of the followers of a specific user that the viewer follows.
+func (s *FollowersService) GetKnownFollowers(ctx context.Context, viewerID, userID uuid.UUID, page pagination.Request) (model.UsersPage, error) {
+	if viewerID == uuid.Nil || userID == uuid.Nil {
+		return model.UsersPage{}, ErrNilID
+	}
+
+	userIDs, count, hasMore, err := s.followersRepository.GetKnownFollowers(ctx, viewerID, userID, page)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get followers for user with ID %s: %w", userID, err)
+		return model.UsersPage{}, fmt.Errorf("failed to get known followers of user with ID %s: %w", userID, err)
 	}
 
-	return followers, nil
+	return newUsersPage(userIDs, count, hasMore, page), nil
+}
+
+// newUsersPage builds the response page and its cursors from a slice of user IDs in listing order.
+func newUsersPage(userIDs []uuid.UUID, count *int, hasMore bool, page pagination.Request) model.UsersPage {
+	next, prev := page.Cursors(len(userIDs), hasMore, func(i int) pagination.Cursor {
+		return pagination.Cursor{ID: userIDs[i]}
+	})
+
+	return model.UsersPage{
+		UserIDs:    userIDs,
+		Count:      count,
+		NextCursor: next,
+		PrevCursor: prev,
+	}
 }
 
-// GetFollowing retrieves a list of users a specific user is following.
-func (s *FollowersService) GetFollowing(ctx context.Context, userID uuid.UUID) ([]model.Follow, error) {
+// GetSuggestions retrieves one page of users a specific user may want to follow, best first.
+func (s *FollowersService) GetSuggestions(ctx context.Context, userID uuid.UUID, page pagination.Request) (model.SuggestionsPage, error) {
 	if userID == uuid.Nil {
-		return nil, fmt.Errorf("userID cannot be nil")
+		return model.SuggestionsPage{}, ErrNilID
+	}
+	if page.Backward() {
+		return model.SuggestionsPage{}, ErrBackwardNotSupported
 	}
 
-	following, err := s.followersRepository.GetFollowing(ctx, userID)
+	suggestions, hasMore, err := s.followersRepository.GetSuggestions(ctx, userID, page)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get following for user with ID %s: %w", userID, err)
+		return model.SuggestionsPage{}, fmt.Errorf("failed to get suggestions for user with ID %s: %w", userID, err)
+	}
+
+	for i := range suggestions {
+		suggestions[i].Reason = suggestionReason(suggestions[i])
+	}
+
+	next, _ := page.Cursors(len(suggestions), hasMore, func(i int) pagination.Cursor {
+		return pagination.Cursor{Score: suggestions[i].MutualCount, ID: suggestions[i].UserID}
+	})
+
+	return model.SuggestionsPage{
+		Suggestions: suggestions,
+		NextCursor:  next,
+	}, nil
+}
+
+// reasonNames is how many followees a suggestion reason names, the others are only counted
+const reasonNames = 2
+
+// suggestionReason explains a suggestion with the first followees following the suggested user
+// and the number of the others. It returns nil for a suggestion without a sample of followees.
+func suggestionReason(suggestion model.Suggestion) *model.SuggestionReason {
+	if len(suggestion.FollowedBy) == 0 {
+		return nil
 	}
 
-	return following, nil
+	named := suggestion.FollowedBy[:min(len(suggestion.FollowedBy), reasonNames)]
+	return &model.SuggestionReason{
+		FollowedBy:  named,
+		OthersCount: max(suggestion.MutualCount-len(named), 0),
+	}
 }
 
 // GetFollowersCount retrieves the number of followers for a specific user.
 func (s *FollowersService) GetFollowersCount(ctx context.Context, userID uuid.UUID) (int, error) {
 	if userID == uuid.Nil {
-		return 0, fmt.Errorf("userID cannot be nil")
+		return 0, ErrNilID
 	}
 
 	count, err := s.followersRepository.GetFollowersCount(ctx, userID)
@@ -115,7 +615,7 @@ func (s *FollowersService) GetFollowersCount(ctx context.Context, userID uuid.UU
 // GetFollowingCount retrieves the number of users a specific user is following.
 func (s *FollowersService) GetFollowingCount(ctx context.Context, userID uuid.UUID) (int, error) {
 	if userID == uuid.Nil {
-		return 0, fmt.Errorf("userID cannot be nil")
+		return 0, ErrNilID
 	}
 
 	count, err := s.followersRepository.GetFollowingCount(ctx, userID)
@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"context"
+	"hornet/api/followers/model"
+	"hornet/api/followers/repository"
+	"hornet/api/followers/service"
+	"hornet/common/pagination"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// graph seeds follows and blocks into an in-memory repository
+type graph struct {
+	t    *testing.T
+	repo *repository.MemoryFollowersRepository
+}
+
+func newGraph(t *testing.T) *graph {
+	return &graph{t: t, repo: repository.NewMemoryFollowersRepository()}
+}
+
+// follow makes every sender follow the receiver
+func (g *graph) follow(receiverID uuid.UUID, senderIDs ...uuid.UUID) {
+	g.t.Helper()
+	for _, senderID := range senderIDs {
+		follow := &model.Follow{ID: uuid.New(), SenderID: senderID, ReceiverID: receiverID, CreatedAt: time.Now().UTC()}
+		if _, _, err := g.repo.CreateFollow(context.Background(), follow); err != nil {
+			g.t.Fatalf("CreateFollow: %v", err)
+		}
+	}
+}
+
+// block makes the blocker block the blocked user
+func (g *graph) block(blockerID, blockedID uuid.UUID) {
+	g.t.Helper()
+	block := &model.Block{BlockerID: blockerID, BlockedID: blockedID, CreatedAt: time.Now().UTC()}
+	if _, _, err := g.repo.CreateBlock(context.Background(), block); err != nil {
+		g.t.Fatalf("CreateBlock: %v", err)
+	}
+}
+
+// sortedIDs returns n new user IDs in ascending order, the order equal scores are ranked in
+func sortedIDs(n int) []uuid.UUID {
+	ids := make([]uuid.UUID, n)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	return ids
+}
+
+func TestGetSuggestions(t *testing.T) {
+	ctx := context.Background()
+	g := newGraph(t)
+
+	user := uuid.New()
+	followees := sortedIDs(4)
+	g.follow(followees[0], user)
+	g.follow(followees[1], user)
+	g.follow(followees[2], user)
+	g.follow(followees[3], user)
+
+	// Ranked by how many followees follow them, then by ID
+	many, two := uuid.New(), uuid.New()
+	ones := sortedIDs(3)
+	g.follow(many, followees[0], followees[1], followees[2])
+	g.follow(two, followees[1], followees[3])
+	for _, id := range ones {
+		g.follow(id, followees[2])
+	}
+
+	// Left out: the user, users they follow, and blocks in either direction
+	followed := uuid.New()
+	g.follow(followed, user, followees[0], followees[1])
+	g.follow(followees[3], followees[0])
+	g.follow(user, followees[0])
+	blocked, blocker := uuid.New(), uuid.New()
+	g.follow(blocked, followees[0], followees[1], followees[2])
+	g.follow(blocker, followees[0], followees[1], followees[2])
+	g.block(user, blocked)
+	g.block(blocker, user)
+
+	followersService := service.NewFollowersService(g.repo)
+
+	first, err := followersService.GetSuggestions(ctx, user, pagination.Request{Limit: 3})
+	if err != nil {
+		t.Fatalf("GetSuggestions: %v", err)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("first page: no next cursor")
+	}
+
+	cursor, err := pagination.DecodeCursor(first.NextCursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	second, err := followersService.GetSuggestions(ctx, user, pagination.Request{Limit: 3, After: &cursor})
+	if err != nil {
+		t.Fatalf("GetSuggestions after: %v", err)
+	}
+	if second.NextCursor != "" {
+		t.Fatalf("second page: next cursor %q, want none", second.NextCursor)
+	}
+
+	want := []struct {
+		userID uuid.UUID
+		count  int
+		reason model.SuggestionReason
+	}{
+		{many, 3, model.SuggestionReason{FollowedBy: []uuid.UUID{followees[0], followees[1]}, OthersCount: 1}},
+		{two, 2, model.SuggestionReason{FollowedBy: []uuid.UUID{followees[1], followees[3]}}},
+		{ones[0], 1, model.SuggestionReason{FollowedBy: []uuid.UUID{followees[2]}}},
+		{ones[1], 1, model.SuggestionReason{FollowedBy: []uuid.UUID{followees[2]}}},
+		{ones[2], 1, model.SuggestionReason{FollowedBy: []uuid.UUID{followees[2]}}},
+	}
+
+	suggestions := append(first.Suggestions, second.Suggestions...)
+	if len(first.Suggestions) != 3 || len(suggestions) != len(want) {
+		t.Fatalf("got pages of %d and %d suggestions, want 3 and %d", len(first.Suggestions), len(second.Suggestions), len(want)-3)
+	}
+	for i, w := range want {
+		got := suggestions[i]
+		if got.UserID != w.userID || got.MutualCount != w.count || got.Reason == nil || !sameReason(*got.Reason, w.reason) {
+			t.Errorf("suggestion %d: got %s (%d, %+v), want %s (%d, %+v)", i, got.UserID, got.MutualCount, got.Reason, w.userID, w.count, w.reason)
+		}
+	}
+}
+
+// sameReason reports whether two suggestion reasons name the same followees, in order, and count the same others
+func sameReason(a, b model.SuggestionReason) bool {
+	if len(a.FollowedBy) != len(b.FollowedBy) || a.OthersCount != b.OthersCount {
+		return false
+	}
+	for i := range a.FollowedBy {
+		if a.FollowedBy[i] != b.FollowedBy[i] {
+			return false
+		}
+	}
+	return true
+}
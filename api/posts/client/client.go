@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"hornet/api/posts/model"
+	"hornet/common/httpclient"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// Client calls the posts service. Requests are sent on behalf of the user set on the
+// context with httpclient.WithUserID, or whose token is set with httpclient.WithBearerToken
+// when the service verifies tokens. Error statuses are returned as *httpclient.StatusError.
+type Client struct {
+	http *httpclient.Client
+}
+
+// New creates a Client for the posts service at baseURL. A nil httpClient uses httpclient.DefaultTimeout.
+func New(baseURL string, httpClient *http.Client) *Client {
+	return &Client{http: httpclient.New(baseURL, httpClient)}
+}
+
+// CreatePost creates a post, reply or share authored by the requesting user.
+func (c *Client) CreatePost(ctx context.Context, req model.CreatePost) (model.Post, error) {
+	if userID, ok := httpclient.UserIDFrom(ctx); ok && req.AuthorID == uuid.Nil {
+		req.AuthorID = userID
+	}
+
+	var post model.Post
+	_, err := c.http.Do(ctx, http.MethodPost, "/posts", nil, req, &post)
+	return post, err
+}
+
+// GetPost retrieves a post, with the requesting user's reactions if one is set.
+func (c *Client) GetPost(ctx context.Context, postID uuid.UUID) (model.Post, error) {
+	var post model.Post
+	_, err := c.http.Do(ctx, http.MethodGet, "/posts/"+postID.String(), nil, nil, &post)
+	return post, err
+}
+
+// GetPostsByAuthor retrieves one page of an author's posts, newest first.
+func (c *Client) GetPostsByAuthor(ctx context.Context, authorID uuid.UUID, page httpclient.Page) (model.PostsPage, error) {
+	var posts model.PostsPage
+	_, err := c.http.Do(ctx, http.MethodGet, "/posts/author/"+authorID.String(), page.Values(), nil, &posts)
+	return posts, err
+}
+
+// GetReplies retrieves one page of the direct replies to a post, in the given order.
+func (c *Client) GetReplies(ctx context.Context, postID uuid.UUID, order model.SortOrder, page httpclient.Page) (model.PostsPage, error) {
+	query := page.Values()
+	if order != "" {
+		query.Set("sort", string(order))
+	}
+
+	var replies model.PostsPage
+	_, err := c.http.Do(ctx, http.MethodGet, "/posts/"+postID.String()+"/replies", query, nil, &replies)
+	return replies, err
+}
+
+// EditPost replaces the content of a post authored by the requesting user.
+func (c *Client) EditPost(ctx context.Context, postID uuid.UUID, req model.EditPost) (model.Post, error) {
+	var post model.Post
+	_, err := c.http.Do(ctx, http.MethodPatch, "/posts/"+postID.String(), nil, req, &post)
+	return post, err
+}
+
+// GetRevisions retrieves the previous versions of a post.
+func (c *Client) GetRevisions(ctx context.Context, postID uuid.UUID) ([]model.PostRevision, error) {
+	var revisions []model.PostRevision
+	_, err := c.http.Do(ctx, http.MethodGet, "/posts/"+postID.String()+"/revisions", nil, nil, &revisions)
+	return revisions, err
+}
+
+// AddReaction reacts to a post with the given kind on behalf of the requesting user.
+func (c *Client) AddReaction(ctx context.Context, postID uuid.UUID, kind string) error {
+	_, err := c.http.Do(ctx, http.MethodPut, reactionPath(postID, kind), nil, nil, nil)
+	return err
+}
+
+// RemoveReaction removes a reaction of the requesting user from a post.
+func (c *Client) RemoveReaction(ctx context.Context, postID uuid.UUID, kind string) error {
+	_, err := c.http.Do(ctx, http.MethodDelete, reactionPath(postID, kind), nil, nil, nil)
+	return err
+}
+
+// GetReactions retrieves one page of the reactions to a post, newest first, optionally of a single kind.
+func (c *Client) GetReactions(ctx context.Context, postID uuid.UUID, kind string, page httpclient.Page) (model.ReactionsPage, error) {
+	query := page.Values()
+	if kind != "" {
+		query.Set("kind", kind)
+	}
+
+	var reactions model.ReactionsPage
+	_, err := c.http.Do(ctx, http.MethodGet, "/posts/"+postID.String()+"/reactions", query, nil, &reactions)
+	return reactions, err
+}
+
+// DeletePost deletes a post. Moderators deleting another user's post must give a reason,
+// and set their roles on the context with httpclient.WithRoles.
+func (c *Client) DeletePost(ctx context.Context, postID uuid.UUID, reason string) error {
+	query := url.Values{}
+	if reason != "" {
+		query.Set("reason", reason)
+	}
+
+	_, err := c.http.Do(ctx, http.MethodDelete, "/posts/"+postID.String(), query, nil, nil)
+	return err
+}
+
+// RestorePost restores a deleted post within the restore window. Authors restore the posts
+// they deleted themselves, moderators restoring any other post must give a reason.
+func (c *Client) RestorePost(ctx context.Context, postID uuid.UUID, reason string) (model.Post, error) {
+	query := url.Values{}
+	if reason != "" {
+		query.Set("reason", reason)
+	}
+
+	var post model.Post
+	_, err := c.http.Do(ctx, http.MethodPost, "/posts/"+postID.String()+"/restore", query, nil, &post)
+	return post, err
+}
+
+// reactionPath returns the path of a reaction kind on a post
+func reactionPath(postID uuid.UUID, kind string) string {
+	return "/posts/" + postID.String() + "/reactions/" + url.PathEscape(kind)
+}
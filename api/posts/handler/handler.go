@@ -3,35 +3,46 @@ package handler
 import (
 	"hornet/api/posts/model"
 	"hornet/api/posts/service"
+	"hornet/common/auth"
 	"hornet/common/logger"
+	"hornet/common/pagination"
+	"hornet/common/problem"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// maxContentLength is the longest content a post may have, in bytes
+const maxContentLength = 5000
+
+var (
+	errInvalidPostID   = problem.BadRequest("invalid_post_id", "Invalid post ID")
+	errInvalidAuthorID = problem.BadRequest("invalid_author_id", "Invalid author ID")
+	errInvalidBody     = problem.BadRequest("invalid_body", "Invalid request body")
+	errMissingContent  = problem.BadRequest("missing_content", "Content is required when creating a new post")
+	errInvalidContent  = problem.BadRequest("invalid_content", "Content length should be between 1 and 5000 characters")
+	errMissingVersion  = problem.BadRequest("missing_version", "Version is required when editing a post")
+	errInvalidSort     = problem.BadRequest("invalid_sort", "sort must be one of oldest, newest or top")
+)
+
 // GetPost handles the retrieval of a post by its ID
 func GetPost(postService *service.PostService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		postIDStr := c.Param("id")
-
-		postID, err := uuid.Parse(postIDStr)
-		if err != nil {
-			logger.WithContext(c).Error("Invalid post ID ", postIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		postID, ok := parsePostID(c)
+		if !ok {
 			return
 		}
 
-		post, err := postService.GetPost(c.Request.Context(), postID)
-		if err != nil {
-			logger.WithContext(c).Error("Error retrieving post ", postID, " error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		// The viewer is optional, it only adds their own reactions to the post
+		viewerID := uuid.Nil
+		if principal, ok := auth.PrincipalFrom(c); ok {
+			viewerID = principal.UserID
 		}
 
-		if post.ID == uuid.Nil {
-			logger.WithContext(c).Info("Post not found ", postID)
-			c.JSON(http.StatusNotFound, gin.H{"message": "Post not found"})
+		post, err := postService.GetPost(c.Request.Context(), postID, viewerID)
+		if err != nil {
+			problem.Respond(c, err)
 			return
 		}
 
@@ -43,29 +54,25 @@ func GetPost(postService *service.PostService) gin.HandlerFunc {
 // GetPostsByAuthor handles the retrieval of posts by an author
 func GetPostsByAuthor(postService *service.PostService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authorIDStr := c.Param("author_id")
-
-		authorID, err := uuid.Parse(authorIDStr)
+		authorID, err := uuid.Parse(c.Param("author_id"))
 		if err != nil {
-			logger.WithContext(c).Error("Invalid author ID ", authorIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+			problem.Respond(c, errInvalidAuthorID)
 			return
 		}
 
-		posts, err := postService.GetPostsByAuthor(c.Request.Context(), authorID)
+		page, err := pagination.ParseRequest(c)
 		if err != nil {
-			logger.WithContext(c).Error("Error retrieving posts for author ", authorID, " error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
-		if len(posts) == 0 {
-			logger.WithContext(c).Info("No posts found for author ", authorID)
-			c.JSON(http.StatusNotFound, gin.H{"message": "No posts found"})
+		posts, err := postService.GetPostsByAuthor(c.Request.Context(), authorID, page)
+		if err != nil {
+			problem.Respond(c, err)
 			return
 		}
 
-		logger.WithContext(c).Info("Posts retrieved successfully for author ", authorID, " repliesCount: ", len(posts))
+		logger.WithContext(c).Info("Posts retrieved successfully for author ", authorID, " postsCount: ", len(posts.Posts))
 		c.JSON(http.StatusOK, posts)
 	}
 }
@@ -73,46 +80,33 @@ func GetPostsByAuthor(postService *service.PostService) gin.HandlerFunc {
 // CreatePost handles the creation of a new post
 func CreatePost(postService *service.PostService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var req model.CreatePost
-
-		authorIDStr := c.GetHeader("X-User-ID")
-		if authorIDStr == "" {
-			logger.WithContext(c).Warn("Missing X-User-ID header")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "X-User-ID header is required"})
-			return
-		}
-
-		authorID, err := uuid.Parse(authorIDStr)
-		if err != nil {
-			logger.WithContext(c).Error("Invalid AuthorID ", authorID, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid AuthorID"})
+		actor, ok := parseActor(c)
+		if !ok {
 			return
 		}
 
-		req.AuthorID = authorID
-
+		var req model.CreatePost
 		if err := c.ShouldBindJSON(&req); err != nil {
-			logger.WithContext(c).Error("Invalid request body ", " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			problem.Respond(c, errInvalidBody)
 			return
 		}
 
+		// The author is the authenticated user, whatever the body claims
+		req.AuthorID = actor.UserID
+
 		if req.OriginalPostID == nil && req.Content == nil {
-			logger.WithContext(c).Warn("Missing content for new post ", authorID)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Content is required when creating a new post"})
+			problem.Respond(c, errMissingContent)
 			return
 		}
 
-		if req.Content != nil && (len(*req.Content) > 5000 || len(*req.Content) == 0) {
-			logger.WithContext(c).Warn("Invalid content length ", authorID, " contentLength: ", len(*req.Content))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Content length should be between 1 and 5000 characters"})
+		if req.Content != nil && (len(*req.Content) > maxContentLength || len(*req.Content) == 0) {
+			problem.Respond(c, errInvalidContent)
 			return
 		}
 
 		post, err := postService.CreatePost(c.Request.Context(), req)
 		if err != nil {
-			logger.WithContext(c).Error("Error creating post ", "error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
@@ -124,19 +118,25 @@ func CreatePost(postService *service.PostService) gin.HandlerFunc {
 // DeletePost handles the deletion of a post by its ID
 func DeletePost(postService *service.PostService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		postIDStr := c.Param("id")
+		actor, ok := parseActor(c)
+		if !ok {
+			return
+		}
 
-		postID, err := uuid.Parse(postIDStr)
-		if err != nil {
-			logger.WithContext(c).Error("Invalid post ID ", postIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		postID, ok := parsePostID(c)
+		if !ok {
 			return
 		}
 
-		err = postService.DeletePost(c.Request.Context(), postID)
+		// Replies stay in place under the tombstone of the deleted post
+		opts := model.DeleteOptions{
+			Soft:            true,
+			ModeratorReason: c.Query("reason"),
+		}
+
+		err := postService.DeletePost(c.Request.Context(), actor, postID, opts)
 		if err != nil {
-			logger.WithContext(c).Error("Error deleting post ", postID, " error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
@@ -145,31 +145,216 @@ func DeletePost(postService *service.PostService) gin.HandlerFunc {
 	}
 }
 
+// EditPost handles editing the content of a post by its author
+func EditPost(postService *service.PostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := parseActor(c)
+		if !ok {
+			return
+		}
+
+		postID, ok := parsePostID(c)
+		if !ok {
+			return
+		}
+
+		var req model.EditPost
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Respond(c, errInvalidBody)
+			return
+		}
+
+		if req.Version == nil {
+			problem.Respond(c, errMissingVersion)
+			return
+		}
+
+		if req.Content == nil || len(*req.Content) > maxContentLength || len(*req.Content) == 0 {
+			problem.Respond(c, errInvalidContent)
+			return
+		}
+
+		post, err := postService.EditPost(c.Request.Context(), actor, postID, req)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Post edited successfully ", postID, " version: ", post.Version)
+		c.JSON(http.StatusOK, post)
+	}
+}
+
+// GetRevisions handles retrieval of the revision history of a post
+func GetRevisions(postService *service.PostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, ok := parsePostID(c)
+		if !ok {
+			return
+		}
+
+		revisions, err := postService.GetRevisions(c.Request.Context(), postID)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Revisions retrieved successfully ", postID, " revisionsCount: ", len(revisions))
+		c.JSON(http.StatusOK, revisions)
+	}
+}
+
+// RestorePost handles restoring a deleted post by its author, or by a moderator given a reason
+func RestorePost(postService *service.PostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := parseActor(c)
+		if !ok {
+			return
+		}
+
+		postID, ok := parsePostID(c)
+		if !ok {
+			return
+		}
+
+		post, err := postService.RestorePost(c.Request.Context(), actor, postID, c.Query("reason"))
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Post restored successfully ", postID)
+		c.JSON(http.StatusOK, post)
+	}
+}
+
+// AddReaction handles a user reacting to a post with a kind of reaction
+func AddReaction(postService *service.PostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := parseActor(c)
+		if !ok {
+			return
+		}
+
+		postID, ok := parsePostID(c)
+		if !ok {
+			return
+		}
+
+		kind := c.Param("kind")
+
+		err := postService.AddReaction(c.Request.Context(), actor.UserID, postID, kind)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Reaction added successfully ", postID, " kind: ", kind)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RemoveReaction handles a user withdrawing a kind of reaction from a post
+func RemoveReaction(postService *service.PostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := parseActor(c)
+		if !ok {
+			return
+		}
+
+		postID, ok := parsePostID(c)
+		if !ok {
+			return
+		}
+
+		kind := c.Param("kind")
+
+		err := postService.RemoveReaction(c.Request.Context(), actor.UserID, postID, kind)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Reaction removed successfully ", postID, " kind: ", kind)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetReactions handles retrieval of who reacted to a post
+func GetReactions(postService *service.PostService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID, ok := parsePostID(c)
+		if !ok {
+			return
+		}
+
+		page, err := pagination.ParseRequest(c)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		reactions, err := postService.GetReactions(c.Request.Context(), postID, c.Query("kind"), page)
+		if err != nil {
+			problem.Respond(c, err)
+			return
+		}
+
+		logger.WithContext(c).Info("Reactions retrieved successfully ", postID, " reactionsCount: ", len(reactions.Reactions))
+		c.JSON(http.StatusOK, reactions)
+	}
+}
+
 // GetReplies handles retrieval of replies for a post
 func GetReplies(postService *service.PostService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		parentPostIDStr := c.Param("id")
-		parentPostID, err := uuid.Parse(parentPostIDStr)
-		if err != nil {
-			logger.WithContext(c).Error("Invalid parent post ID ", parentPostIDStr, " error: ", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent post ID"})
+		parentPostID, ok := parsePostID(c)
+		if !ok {
+			return
+		}
+
+		order := model.SortOrder(c.DefaultQuery("sort", string(model.SortOldest)))
+		if !order.Valid() {
+			problem.Respond(c, errInvalidSort)
 			return
 		}
 
-		replies, err := postService.GetReplies(c.Request.Context(), parentPostID)
+		page, err := pagination.ParseRequest(c)
 		if err != nil {
-			logger.WithContext(c).Error("Error fetching replies ", parentPostID, " error: ", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			problem.Respond(c, err)
 			return
 		}
 
-		if len(replies) == 0 {
-			logger.WithContext(c).Info("No replies found ", parentPostID)
-			c.JSON(http.StatusOK, gin.H{"message": "No replies found"})
+		replies, err := postService.GetReplies(c.Request.Context(), parentPostID, order, page)
+		if err != nil {
+			problem.Respond(c, err)
 			return
 		}
 
-		logger.WithContext(c).Info("Replies retrieved successfully ", parentPostID, " repliesCount: ", len(replies))
+		logger.WithContext(c).Info("Replies retrieved successfully ", parentPostID, " repliesCount: ", len(replies.Posts))
 		c.JSON(http.StatusOK, replies)
 	}
 }
+
+// parsePostID reads the id path parameter.
+// It writes the error response and returns false when it is invalid.
+func parsePostID(c *gin.Context) (uuid.UUID, bool) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, errInvalidPostID)
+		return uuid.Nil, false
+	}
+	return postID, true
+}
+
+// parseActor returns the authenticated user acting on the request.
+// It writes the error response and returns false when the request is unauthenticated.
+func parseActor(c *gin.Context) (model.Actor, bool) {
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok {
+		problem.Respond(c, auth.ErrUnauthenticated)
+		return model.Actor{}, false
+	}
+
+	return model.Actor{UserID: principal.UserID, Roles: principal.Roles}, true
+}
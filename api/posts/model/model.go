@@ -8,14 +8,27 @@ import (
 
 // Post represents a post document in MongoDB
 type Post struct {
-	ID             uuid.UUID  `bson:"_id,omitempty" json:"id"`
-	Content        string     `bson:"content,omitempty" json:"content,omitempty" validate:"max=5000"`
-	AuthorID       uuid.UUID  `bson:"author_id" json:"author_id" validate:"required"`
-	ParentPostID   *uuid.UUID `bson:"parent_post_id,omitempty" json:"parent_post_id,omitempty"`     // For replies
-	OriginalPostID *uuid.UUID `bson:"original_post_id,omitempty" json:"original_post_id,omitempty"` // For shared posts
-	RepliesCount   int        `bson:"replies_count" json:"replies_count"`                           // For tracking nested replies
-	SharesCount    int        `bson:"shares_count" json:"shares_count"`                             // For tracking shared posts
-	CreatedAt      time.Time  `bson:"created_at" json:"created_at"`
+	ID              uuid.UUID      `bson:"_id,omitempty" json:"id"`
+	Content         string         `bson:"content,omitempty" json:"content,omitempty" validate:"max=5000"`
+	AuthorID        uuid.UUID      `bson:"author_id" json:"author_id" validate:"required"`
+	ParentPostID    *uuid.UUID     `bson:"parent_post_id,omitempty" json:"parent_post_id,omitempty"`     // For replies
+	OriginalPostID  *uuid.UUID     `bson:"original_post_id,omitempty" json:"original_post_id,omitempty"` // For shared posts
+	RepliesCount    int            `bson:"replies_count" json:"replies_count"`                           // For tracking nested replies
+	SharesCount     int            `bson:"shares_count" json:"shares_count"`                             // For tracking shared posts
+	Score           int            `bson:"score" json:"-"`                                               // replies_count + shares_count, stored so the top sort can use an index
+	CreatedAt       time.Time      `bson:"created_at" json:"created_at"`
+	EditedAt        *time.Time     `bson:"edited_at,omitempty" json:"edited_at,omitempty"`   // Set once the content has been edited
+	Version         int            `bson:"version" json:"version"`                           // Incremented by every edit, delete and restore, for optimistic concurrency and ordering feed updates
+	DeletedAt       *time.Time     `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // Set while the post is a tombstone
+	DeletedContent  string         `bson:"deleted_content,omitempty" json:"-"`               // Stripped content, kept to restore the post
+	Moderated       bool           `bson:"moderated,omitempty" json:"moderated,omitempty"`   // Set while the post is a tombstone a moderator deleted
+	Reactions       map[string]int `bson:"reactions,omitempty" json:"reactions,omitempty"`   // Number of reactions per kind
+	ViewerReactions []string       `bson:"-" json:"viewer_reactions,omitempty"`              // Kinds the requesting user reacted with
+}
+
+// IsDeleted reports whether the post is a tombstone, shown as "this post was deleted"
+func (p Post) IsDeleted() bool {
+	return p.DeletedAt != nil
 }
 
 // CreatePost represents the structure of a new post creation request
@@ -23,5 +36,118 @@ type CreatePost struct {
 	Content        *string    `json:"content,omitempty"`          // Content is optional, only when original_post_id is provided
 	ParentPostID   *uuid.UUID `json:"parent_post_id,omitempty"`   // ID of the parent post if it's a reply, can be nil
 	OriginalPostID *uuid.UUID `json:"original_post_id,omitempty"` // ID of the original post being shared, can be nil
-	AuthorID       uuid.UUID  `json:"author_id"`                  // AuthorID is required and represents the user making the post
+	AuthorID       uuid.UUID  `json:"author_id"`                  // AuthorID is set from the authenticated user making the post
+}
+
+// EditPost represents the structure of a post edit request
+type EditPost struct {
+	Content *string `json:"content"` // The new content of the post
+	Version *int    `json:"version"` // The version of the post the edit is based on
+}
+
+// PostRevision represents a previous version of an edited post
+type PostRevision struct {
+	ID         uuid.UUID `bson:"_id" json:"id"`
+	PostID     uuid.UUID `bson:"post_id" json:"post_id"`
+	Version    int       `bson:"version" json:"version"` // Version of the post that held this content
+	Content    string    `bson:"content" json:"content"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`   // When this content was written
+	ReplacedAt time.Time `bson:"replaced_at" json:"replaced_at"` // When an edit replaced this content
+}
+
+// ReactionKinds lists the kinds of reactions a user can leave on a post
+var ReactionKinds = []string{"like", "love", "laugh", "wow", "sad", "angry"}
+
+// ValidReactionKind reports whether the kind is one of ReactionKinds
+func ValidReactionKind(kind string) bool {
+	for _, k := range ReactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Reaction represents a user reacting to a post, at most once per kind
+type Reaction struct {
+	ID        uuid.UUID `bson:"_id" json:"id"`
+	PostID    uuid.UUID `bson:"post_id" json:"post_id"`
+	UserID    uuid.UUID `bson:"user_id" json:"user_id"`
+	Kind      string    `bson:"kind" json:"kind"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// ReactionsPage represents one page of a cursor-paginated list of reactions
+type ReactionsPage struct {
+	Reactions  []Reaction `json:"reactions"`
+	NextCursor string     `json:"next_cursor,omitempty"` // Pass as `after` to fetch the following page
+	PrevCursor string     `json:"prev_cursor,omitempty"` // Pass as `before` to fetch the preceding page
+}
+
+// RoleModerator is the role that allows acting on posts owned by other users
+const RoleModerator = "moderator"
+
+// Actor represents the user performing a request, as authenticated by the auth middleware
+type Actor struct {
+	UserID uuid.UUID
+	Roles  []string
+}
+
+// IsModerator reports whether the actor holds the moderator role
+func (a Actor) IsModerator() bool {
+	for _, role := range a.Roles {
+		if role == RoleModerator {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteOptions controls how a post is deleted
+type DeleteOptions struct {
+	Soft            bool   // Turn the posts into restorable tombstones instead of removing them
+	Cascade         bool   // Also delete the replies to the post, recursively
+	ModeratorReason string // Why a moderator deleted another user's post, required for them
+}
+
+// ModerationAction represents an audit record of a moderator acting on another user's post
+type ModerationAction struct {
+	ID          uuid.UUID `bson:"_id" json:"id"`
+	Action      string    `bson:"action" json:"action"` // The action taken, e.g. "delete"
+	PostID      uuid.UUID `bson:"post_id" json:"post_id"`
+	AuthorID    uuid.UUID `bson:"author_id" json:"author_id"`       // Owner of the post
+	ModeratorID uuid.UUID `bson:"moderator_id" json:"moderator_id"` // Moderator who took the action
+	Reason      string    `bson:"reason" json:"reason"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+// FeedUpdate is a pending update of the feeds for a post, kept until the feed service has it.
+// It is delivered with the state of the post at that time, so later writes of the post are coalesced into it.
+type FeedUpdate struct {
+	PostID         uuid.UUID `bson:"_id"`
+	DeletedVersion int       `bson:"deleted_version"` // The version a hard-deleted post took, retracted once the post is gone
+	QueuedAt       time.Time `bson:"queued_at"`       // When the latest write of the post queued the update
+	Attempts       int       `bson:"attempts"`        // Deliveries attempted since then
+	NextAttemptAt  time.Time `bson:"next_attempt_at"` // When the update is due, or until when a delivery holds it
+}
+
+// SortOrder defines the order in which a list of posts is returned
+type SortOrder string
+
+const (
+	SortNewest SortOrder = "newest" // Most recent posts first
+	SortOldest SortOrder = "oldest" // Earliest posts first
+	SortTop    SortOrder = "top"    // Highest replies_count + shares_count first
+)
+
+// Valid reports whether the sort order is one of the supported values
+func (o SortOrder) Valid() bool {
+	return o == SortNewest || o == SortOldest || o == SortTop
+}
+
+// PostsPage represents one page of a cursor-paginated list of posts
+type PostsPage struct {
+	Posts      []Post `json:"posts"`
+	NextCursor string `json:"next_cursor,omitempty"` // Pass as `after` to fetch the following page
+	PrevCursor string `json:"prev_cursor,omitempty"` // Pass as `before` to fetch the preceding page
 }
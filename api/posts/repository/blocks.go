@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	followersclient "hornet/api/followers/client"
+	"hornet/common/httpclient"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxCachedBlocks bounds the block cache, expired entries are swept once it is full
+const maxCachedBlocks = 10000
+
+// BlockRepository looks up blocks between users
+type BlockRepository interface {
+	// IsBlocked reports whether the blocker blocks the blocked user
+	IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+}
+
+// HTTPBlockRepository looks up blocks in the followers service, which owns them, with the service token.
+// Answers are cached for a short TTL, so a new block or unblock takes up to that long to apply.
+type HTTPBlockRepository struct {
+	followers    *followersclient.Client
+	serviceToken string
+	ttl          time.Duration
+
+	mu    sync.Mutex
+	cache map[blockKey]cachedBlock
+}
+
+// blockKey identifies a directed blocker to blocked pair
+type blockKey struct {
+	blockerID uuid.UUID
+	blockedID uuid.UUID
+}
+
+// cachedBlock is a block lookup result and the time it stops being trusted
+type cachedBlock struct {
+	blocked   bool
+	expiresAt time.Time
+}
+
+// NewHTTPBlockRepository creates an HTTPBlockRepository for the followers service at baseURL
+func NewHTTPBlockRepository(baseURL, serviceToken string, ttl time.Duration) *HTTPBlockRepository {
+	return &HTTPBlockRepository{
+		followers:    followersclient.New(baseURL, &http.Client{Timeout: 5 * time.Second}),
+		serviceToken: serviceToken,
+		ttl:          ttl,
+		cache:        make(map[blockKey]cachedBlock),
+	}
+}
+
+// IsBlocked reports whether the blocker blocks the blocked user
+func (r *HTTPBlockRepository) IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	key := blockKey{blockerID: blockerID, blockedID: blockedID}
+
+	r.mu.Lock()
+	entry, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.blocked, nil
+	}
+
+	blocked, err := r.followers.IsBlocked(httpclient.WithServiceToken(ctx, r.serviceToken), blockerID, blockedID)
+	if err != nil {
+		return false, err
+	}
+
+	r.store(key, blocked)
+	return blocked, nil
+}
+
+// store caches a lookup result, sweeping expired entries when the cache is full
+func (r *HTTPBlockRepository) store(key blockKey, blocked bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if len(r.cache) >= maxCachedBlocks {
+		for k, entry := range r.cache {
+			if !now.Before(entry.expiresAt) {
+				delete(r.cache, k)
+			}
+		}
+		// Every entry is still fresh, start over rather than grow without bound
+		if len(r.cache) >= maxCachedBlocks {
+			r.cache = make(map[blockKey]cachedBlock)
+		}
+	}
+
+	r.cache[key] = cachedBlock{blocked: blocked, expiresAt: now.Add(r.ttl)}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"hornet/api/posts/model"
+	"hornet/common/httpclient"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeedRequestTimeout bounds a feed update, fan-out to many followers included
+const FeedRequestTimeout = time.Minute
+
+// FeedRepository keeps the feeds up to date with the posts. Updates may arrive out of order
+// or more than once, so each carries the version of the post it reflects and older versions are ignored.
+type FeedRepository interface {
+	// PublishPost sends a new, edited or restored post to the feeds
+	PublishPost(ctx context.Context, post model.Post) error
+
+	// RetractPost removes a deleted post from the feeds, leaving a tombstone at the given version
+	RetractPost(ctx context.Context, postID uuid.UUID, version int) error
+}
+
+// HTTPFeedRepository keeps the feed service's snapshots of posts up to date
+// through its internal routes, which only accept calls carrying the service token.
+type HTTPFeedRepository struct {
+	client       *httpclient.Client
+	serviceToken string
+}
+
+// NewHTTPFeedRepository creates an HTTPFeedRepository for the feed service at baseURL
+func NewHTTPFeedRepository(baseURL, serviceToken string) *HTTPFeedRepository {
+	return &HTTPFeedRepository{
+		client:       httpclient.New(baseURL, &http.Client{Timeout: FeedRequestTimeout}),
+		serviceToken: serviceToken,
+	}
+}
+
+// PublishPost sends a new, edited or restored post to the feed service
+func (r *HTTPFeedRepository) PublishPost(ctx context.Context, post model.Post) error {
+	ctx = httpclient.WithServiceToken(ctx, r.serviceToken)
+	_, err := r.client.Do(ctx, http.MethodPost, "/internal/feed/posts", nil, post, nil)
+	return err
+}
+
+// RetractPost asks the feed service to remove a deleted post from the feeds
+func (r *HTTPFeedRepository) RetractPost(ctx context.Context, postID uuid.UUID, version int) error {
+	ctx = httpclient.WithServiceToken(ctx, r.serviceToken)
+	query := url.Values{"version": {strconv.Itoa(version)}}
+	_, err := r.client.Do(ctx, http.MethodDelete, "/internal/feed/posts/"+postID.String(), query, nil, nil)
+	return err
+}
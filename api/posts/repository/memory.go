@@ -0,0 +1,625 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hornet/api/posts/model"
+	"hornet/common/pagination"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryPostRepository stores posts in memory. It is safe for concurrent use, and runs
+// transactions without isolation, the way MongoDB deployments without replica sets do.
+type MemoryPostRepository struct {
+	mu            sync.RWMutex
+	posts         map[uuid.UUID]model.Post
+	revisions     map[uuid.UUID]model.PostRevision
+	reactions     map[uuid.UUID]model.Reaction
+	moderationLog []model.ModerationAction
+	feedUpdates   map[uuid.UUID]model.FeedUpdate
+}
+
+// NewMemoryPostRepository creates an empty MemoryPostRepository
+func NewMemoryPostRepository() *MemoryPostRepository {
+	return &MemoryPostRepository{
+		posts:       make(map[uuid.UUID]model.Post),
+		revisions:   make(map[uuid.UUID]model.PostRevision),
+		reactions:   make(map[uuid.UUID]model.Reaction),
+		feedUpdates: make(map[uuid.UUID]model.FeedUpdate),
+	}
+}
+
+// SupportsTransactions reports false, fn passed to WithTransaction compensates its own writes
+func (r *MemoryPostRepository) SupportsTransactions() bool {
+	return false
+}
+
+// WithTransaction runs fn directly
+func (r *MemoryPostRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// FindPostByID retrieves a post by its ID
+func (r *MemoryPostRepository) FindPostByID(ctx context.Context, id uuid.UUID) (model.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	post, ok := r.posts[id]
+	if !ok {
+		return model.Post{}, ErrPostNotFound
+	}
+	return clonePost(post), nil
+}
+
+// FindPostsByAuthorID retrieves one page of posts by a given author ID, newest first.
+// It reports whether more posts exist beyond the page in the read direction.
+func (r *MemoryPostRepository) FindPostsByAuthorID(ctx context.Context, authorID uuid.UUID, page pagination.Request) ([]model.Post, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	posts, hasMore := r.findPage(func(post model.Post) bool {
+		return post.AuthorID == authorID && !post.IsDeleted()
+	}, model.SortNewest, page)
+	return posts, hasMore, nil
+}
+
+// FindPostsByParentID retrieves posts by their parent ID (for replies)
+func (r *MemoryPostRepository) FindPostsByParentID(ctx context.Context, id uuid.UUID) ([]model.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	replies := []model.Post{}
+	for _, post := range r.posts {
+		if post.ParentPostID != nil && *post.ParentPostID == id {
+			replies = append(replies, clonePost(post))
+		}
+	}
+	return replies, nil
+}
+
+// FindRepliesPage retrieves one page of replies to a parent post in the given order.
+// It reports whether more replies exist beyond the page in the read direction.
+func (r *MemoryPostRepository) FindRepliesPage(ctx context.Context, parentID uuid.UUID, order model.SortOrder, page pagination.Request) ([]model.Post, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	replies, hasMore := r.findPage(func(post model.Post) bool {
+		return post.ParentPostID != nil && *post.ParentPostID == parentID
+	}, order, page)
+	return replies, hasMore, nil
+}
+
+// SavePost saves a new post
+func (r *MemoryPostRepository) SavePost(ctx context.Context, post model.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.posts[post.ID]; ok {
+		return ErrPostExists
+	}
+
+	post = clonePost(post)
+	post.ViewerReactions = nil
+	post.Score = post.RepliesCount + post.SharesCount
+	r.posts[post.ID] = post
+	return nil
+}
+
+// DeletePost deletes a post by its ID, with its revisions and reactions
+func (r *MemoryPostRepository) DeletePost(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deletePosts(map[uuid.UUID]bool{id: true})
+	return nil
+}
+
+// UpdatePostContent replaces the content of a live post if it is still at the expected version,
+// and bumps its version. It reports false when the post is deleted or was edited concurrently.
+func (r *MemoryPostRepository) UpdatePostContent(ctx context.Context, id uuid.UUID, version int, content string, editedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := r.posts[id]
+	if !ok || post.IsDeleted() || post.Version != version {
+		return false, nil
+	}
+
+	post.Content = content
+	post.EditedAt = &editedAt
+	post.Version++
+	r.posts[id] = post
+	return true, nil
+}
+
+// TombstonePost strips the content of a post and marks it deleted, keeping the content for a restore.
+// It reports false when the post does not exist or is already a tombstone.
+func (r *MemoryPostRepository) TombstonePost(ctx context.Context, id uuid.UUID, deletedAt time.Time, moderated bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := r.posts[id]
+	if !ok || post.IsDeleted() {
+		return false, nil
+	}
+
+	post.DeletedContent = post.Content
+	post.Content = ""
+	post.DeletedAt = &deletedAt
+	post.Moderated = moderated
+	post.Version++
+	r.posts[id] = post
+	return true, nil
+}
+
+// RestorePost brings back the content of a tombstone deleted at or after the given time.
+// It reports false when no such tombstone exists.
+func (r *MemoryPostRepository) RestorePost(ctx context.Context, id uuid.UUID, deletedSince time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := r.posts[id]
+	if !ok || !post.IsDeleted() || post.DeletedAt.Before(deletedSince) {
+		return false, nil
+	}
+
+	post.Content = post.DeletedContent
+	post.DeletedContent = ""
+	post.DeletedAt = nil
+	post.Moderated = false
+	post.Version++
+	r.posts[id] = post
+	return true, nil
+}
+
+// PurgeTombstones hard-deletes the tombstones deleted before the given time, with their revisions.
+// Tombstones that still have live replies or shares are kept so threads and reposts don't dangle,
+// and so are those with restorable ones, as restoring those takes their counters back.
+func (r *MemoryPostRepository) PurgeTombstones(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	referenced := map[uuid.UUID]bool{}
+	for _, post := range r.posts {
+		if !post.IsDeleted() || post.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+		if post.ParentPostID != nil {
+			referenced[*post.ParentPostID] = true
+		}
+		if post.OriginalPostID != nil {
+			referenced[*post.OriginalPostID] = true
+		}
+	}
+
+	expired := map[uuid.UUID]bool{}
+	for id, post := range r.posts {
+		if post.IsDeleted() && post.DeletedAt.Before(deletedBefore) && post.RepliesCount == 0 && post.SharesCount == 0 && !referenced[id] {
+			expired[id] = true
+		}
+	}
+
+	r.deletePosts(expired)
+	return int64(len(expired)), nil
+}
+
+// deletePosts removes the posts with the given IDs along with their revisions and reactions
+func (r *MemoryPostRepository) deletePosts(ids map[uuid.UUID]bool) {
+	for id := range ids {
+		delete(r.posts, id)
+	}
+	for id, revision := range r.revisions {
+		if ids[revision.PostID] {
+			delete(r.revisions, id)
+		}
+	}
+	for id, reaction := range r.reactions {
+		if ids[reaction.PostID] {
+			delete(r.reactions, id)
+		}
+	}
+}
+
+// IncrementCounter increments a counter field of a post
+func (r *MemoryPostRepository) IncrementCounter(ctx context.Context, id uuid.UUID, field string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := r.posts[id]
+	if !ok {
+		return ErrPostNotFound
+	}
+	return r.addToCounter(post, field, 1)
+}
+
+// DecrementCounter decrements a counter field of a post without going below zero
+func (r *MemoryPostRepository) DecrementCounter(ctx context.Context, id uuid.UUID, field string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := r.posts[id]
+	if !ok {
+		return nil
+	}
+	return r.addToCounter(post, field, -1)
+}
+
+// addToCounter adds delta to a counter field of a stored post, leaving counters that would
+// go below zero unchanged
+func (r *MemoryPostRepository) addToCounter(post model.Post, field string, delta int) error {
+	switch {
+	case field == RepliesCountField:
+		if post.RepliesCount+delta >= 0 {
+			post.RepliesCount += delta
+			post.Score += delta
+		}
+	case field == SharesCountField:
+		if post.SharesCount+delta >= 0 {
+			post.SharesCount += delta
+			post.Score += delta
+		}
+	case strings.HasPrefix(field, ReactionsCountField("")):
+		kind := strings.TrimPrefix(field, ReactionsCountField(""))
+		if post.Reactions == nil {
+			post.Reactions = map[string]int{}
+		}
+		if post.Reactions[kind]+delta >= 0 {
+			post.Reactions[kind] += delta
+		}
+	default:
+		return fmt.Errorf("unknown counter field %q", field)
+	}
+
+	r.posts[post.ID] = post
+	return nil
+}
+
+// SaveRevision saves a previous version of a post to its revision history
+func (r *MemoryPostRepository) SaveRevision(ctx context.Context, revision model.PostRevision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, saved := range r.revisions {
+		if saved.PostID == revision.PostID && saved.Version == revision.Version {
+			return ErrRevisionExists
+		}
+	}
+
+	r.revisions[revision.ID] = revision
+	return nil
+}
+
+// DeleteRevision removes a revision from the history of a post
+func (r *MemoryPostRepository) DeleteRevision(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.revisions, id)
+	return nil
+}
+
+// FindRevisions retrieves the revision history of a post, latest first
+func (r *MemoryPostRepository) FindRevisions(ctx context.Context, postID uuid.UUID) ([]model.PostRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := []model.PostRevision{}
+	for _, revision := range r.revisions {
+		if revision.PostID == postID {
+			revisions = append(revisions, revision)
+		}
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Version > revisions[j].Version
+	})
+	return revisions, nil
+}
+
+// AddReaction saves a reaction unless the user already reacted to the post with that kind.
+// It reports whether the reaction was added.
+func (r *MemoryPostRepository) AddReaction(ctx context.Context, reaction model.Reaction) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, saved := range r.reactions {
+		if saved.PostID == reaction.PostID && saved.UserID == reaction.UserID && saved.Kind == reaction.Kind {
+			return false, nil
+		}
+	}
+
+	r.reactions[reaction.ID] = reaction
+	return true, nil
+}
+
+// RemoveReaction deletes the reaction of a user to a post with a kind.
+// It reports whether a reaction was removed.
+func (r *MemoryPostRepository) RemoveReaction(ctx context.Context, postID, userID uuid.UUID, kind string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, saved := range r.reactions {
+		if saved.PostID == postID && saved.UserID == userID && saved.Kind == kind {
+			delete(r.reactions, id)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FindUserReactions retrieves the kinds a user reacted to a post with
+func (r *MemoryPostRepository) FindUserReactions(ctx context.Context, postID, userID uuid.UUID) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kinds := []string{}
+	for _, reaction := range r.reactions {
+		if reaction.PostID == postID && reaction.UserID == userID {
+			kinds = append(kinds, reaction.Kind)
+		}
+	}
+	return kinds, nil
+}
+
+// FindReactionsPage retrieves one page of reactions to a post, newest first, optionally of a single kind.
+// It reports whether more reactions exist beyond the page in the read direction.
+func (r *MemoryPostRepository) FindReactionsPage(ctx context.Context, postID uuid.UUID, kind string, page pagination.Request) ([]model.Reaction, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reactions := []model.Reaction{}
+	for _, reaction := range r.reactions {
+		if reaction.PostID == postID && (kind == "" || reaction.Kind == kind) {
+			reactions = append(reactions, reaction)
+		}
+	}
+
+	keyOf := func(reaction model.Reaction) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: reaction.CreatedAt, ID: reaction.ID}
+	}
+	sortByCursor(reactions, keyOf, -1)
+
+	reactions, hasMore := pagination.Slice(reactions, page, func(reaction model.Reaction, cursor pagination.Cursor) int {
+		return -compareCursors(keyOf(reaction), cursor, false)
+	})
+	return reactions, hasMore, nil
+}
+
+// SaveModerationAction records a moderator action in the moderation log
+func (r *MemoryPostRepository) SaveModerationAction(ctx context.Context, action model.ModerationAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.moderationLog = append(r.moderationLog, action)
+	return nil
+}
+
+// QueueFeedUpdate records that the feeds must catch up with a post, due right away. An update
+// already queued for the post is replaced, keeping the highest deleted version.
+func (r *MemoryPostRepository) QueueFeedUpdate(ctx context.Context, postID uuid.UUID, deletedVersion int, queuedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	update := r.feedUpdates[postID]
+	r.feedUpdates[postID] = model.FeedUpdate{
+		PostID:         postID,
+		DeletedVersion: max(update.DeletedVersion, deletedVersion),
+		QueuedAt:       queuedAt,
+		NextAttemptAt:  queuedAt,
+	}
+	return nil
+}
+
+// ClaimFeedUpdate takes the feed update due the earliest at now, holding it until leaseUntil
+// and counting the attempt. It reports false when no update is due.
+func (r *MemoryPostRepository) ClaimFeedUpdate(ctx context.Context, now, leaseUntil time.Time) (model.FeedUpdate, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var claimed model.FeedUpdate
+	found := false
+	for _, update := range r.feedUpdates {
+		if update.NextAttemptAt.After(now) {
+			continue
+		}
+		if !found || update.NextAttemptAt.Before(claimed.NextAttemptAt) {
+			claimed, found = update, true
+		}
+	}
+	if !found {
+		return model.FeedUpdate{}, false, nil
+	}
+
+	claimed.Attempts++
+	claimed.NextAttemptAt = leaseUntil
+	r.feedUpdates[claimed.PostID] = claimed
+	return claimed, true, nil
+}
+
+// CompleteFeedUpdate deletes a delivered feed update, unless the post queued it again since
+func (r *MemoryPostRepository) CompleteFeedUpdate(ctx context.Context, postID uuid.UUID, queuedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if update, ok := r.feedUpdates[postID]; ok && update.QueuedAt.Equal(queuedAt) {
+		delete(r.feedUpdates, postID)
+	}
+	return nil
+}
+
+// RescheduleFeedUpdate makes a failed feed update due again at nextAttemptAt, unless the post queued it again since
+func (r *MemoryPostRepository) RescheduleFeedUpdate(ctx context.Context, postID uuid.UUID, queuedAt, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if update, ok := r.feedUpdates[postID]; ok && update.QueuedAt.Equal(queuedAt) {
+		update.NextAttemptAt = nextAttemptAt
+		r.feedUpdates[postID] = update
+	}
+	return nil
+}
+
+// findPage cuts one page out of the posts matching the filter, ranked like the MongoDB
+// keyset: by score for top posts, then by created_at and ID
+func (r *MemoryPostRepository) findPage(filter func(post model.Post) bool, order model.SortOrder, page pagination.Request) ([]model.Post, bool) {
+	posts := []model.Post{}
+	for _, post := range r.posts {
+		if filter(post) {
+			posts = append(posts, clonePost(post))
+		}
+	}
+
+	scored := order == model.SortTop
+	keyOf := func(post model.Post) pagination.Cursor {
+		cursor := pagination.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+		if scored {
+			cursor.Score = post.Score
+		}
+		return cursor
+	}
+
+	direction := -1
+	if order == model.SortOldest {
+		direction = 1
+	}
+	sortByCursor(posts, keyOf, direction)
+
+	return pagination.Slice(posts, page, func(post model.Post, cursor pagination.Cursor) int {
+		return direction * compareCursors(keyOf(post), cursor, scored)
+	})
+}
+
+// sortByCursor sorts items by their cursor keys, ascending for direction 1 and descending for -1
+func sortByCursor[T any](items []T, keyOf func(T) pagination.Cursor, direction int) {
+	sort.Slice(items, func(i, j int) bool {
+		return direction*compareCursors(keyOf(items[i]), keyOf(items[j]), true) < 0
+	})
+}
+
+// compareCursors orders two cursors by score when scored, then creation time, then ID
+func compareCursors(a, b pagination.Cursor, scored bool) int {
+	if scored && a.Score != b.Score {
+		if a.Score < b.Score {
+			return -1
+		}
+		return 1
+	}
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+// clonePost copies a post so callers cannot modify the stored one through its pointers and map
+func clonePost(post model.Post) model.Post {
+	if post.Reactions != nil {
+		reactions := make(map[string]int, len(post.Reactions))
+		for kind, count := range post.Reactions {
+			reactions[kind] = count
+		}
+		post.Reactions = reactions
+	}
+	if post.ParentPostID != nil {
+		parentPostID := *post.ParentPostID
+		post.ParentPostID = &parentPostID
+	}
+	if post.OriginalPostID != nil {
+		originalPostID := *post.OriginalPostID
+		post.OriginalPostID = &originalPostID
+	}
+	if post.EditedAt != nil {
+		editedAt := *post.EditedAt
+		post.EditedAt = &editedAt
+	}
+	if post.DeletedAt != nil {
+		deletedAt := *post.DeletedAt
+		post.DeletedAt = &deletedAt
+	}
+	post.ViewerReactions = append([]string(nil), post.ViewerReactions...)
+	return post
+}
+
+// MemoryBlockRepository holds blocks in memory. It is safe for concurrent use.
+type MemoryBlockRepository struct {
+	mu     sync.RWMutex
+	blocks map[blockKey]bool
+}
+
+// NewMemoryBlockRepository creates a MemoryBlockRepository without blocks
+func NewMemoryBlockRepository() *MemoryBlockRepository {
+	return &MemoryBlockRepository{blocks: make(map[blockKey]bool)}
+}
+
+// SetBlocked records whether the blocker blocks the blocked user
+func (r *MemoryBlockRepository) SetBlocked(blockerID, blockedID uuid.UUID, blocked bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := blockKey{blockerID: blockerID, blockedID: blockedID}
+	if blocked {
+		r.blocks[key] = true
+	} else {
+		delete(r.blocks, key)
+	}
+}
+
+// IsBlocked reports whether the blocker blocks the blocked user
+func (r *MemoryBlockRepository) IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.blocks[blockKey{blockerID: blockerID, blockedID: blockedID}], nil
+}
+
+// MemoryFeedRepository records the posts published to the feeds, in memory.
+// It is safe for concurrent use.
+type MemoryFeedRepository struct {
+	mu    sync.RWMutex
+	posts map[uuid.UUID]model.Post
+}
+
+// NewMemoryFeedRepository creates an empty MemoryFeedRepository
+func NewMemoryFeedRepository() *MemoryFeedRepository {
+	return &MemoryFeedRepository{posts: make(map[uuid.UUID]model.Post)}
+}
+
+// PublishPost records a post, unless a newer version or a tombstone of the same version is recorded
+func (r *MemoryFeedRepository) PublishPost(ctx context.Context, post model.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.posts[post.ID]; ok && current.Version >= post.Version {
+		return nil
+	}
+	r.posts[post.ID] = clonePost(post)
+	return nil
+}
+
+// RetractPost replaces a post with a tombstone at the given version, unless a newer version is recorded
+func (r *MemoryFeedRepository) RetractPost(ctx context.Context, postID uuid.UUID, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.posts[postID]; ok && (current.Version > version || current.Version == version && current.IsDeleted()) {
+		return nil
+	}
+	deletedAt := time.Now()
+	r.posts[postID] = model.Post{ID: postID, Version: version, DeletedAt: &deletedAt}
+	return nil
+}
+
+// Post returns the latest published version of a post, a tombstone once retracted,
+// and whether it was ever sent to the feeds
+func (r *MemoryFeedRepository) Post(postID uuid.UUID) (model.Post, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	post, ok := r.posts[postID]
+	return clonePost(post), ok
+}
@@ -0,0 +1,13 @@
+package repository_test
+
+import (
+	"hornet/api/posts/repository"
+	"hornet/api/posts/repository/repositorytest"
+	"testing"
+)
+
+func TestMemoryPostRepository(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.PostRepository {
+		return repository.NewMemoryPostRepository()
+	})
+}
@@ -4,7 +4,8 @@ import (
 	"context"
 	"errors"
 	"hornet/api/posts/model"
-	"sync"
+	"hornet/common/pagination"
+	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,29 +13,203 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// PostRepository defines the methods for interacting with the database
-type PostRepository struct {
-	Collection *mongo.Collection
+var (
+	// ErrPostNotFound is returned when no post matches the given ID
+	ErrPostNotFound = errors.New("post not found")
+
+	// ErrPostExists is returned when saving a post whose ID is already taken
+	ErrPostExists = errors.New("post already exists")
+
+	// ErrRevisionExists is returned when a revision of the same post version was already saved
+	ErrRevisionExists = errors.New("revision already exists")
+)
+
+// PostRepository defines the methods for storing posts, their revisions and their reactions.
+// MongoPostRepository is the production backend, MemoryPostRepository serves tests and local runs.
+type PostRepository interface {
+	// SupportsTransactions reports whether WithTransaction runs its function in a transaction
+	SupportsTransactions() bool
+
+	// WithTransaction runs fn in a transaction when the backend supports them. Otherwise fn
+	// runs directly and is responsible for compensating its own partial writes.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// FindPostByID retrieves a post by its ID, or returns ErrPostNotFound
+	FindPostByID(ctx context.Context, id uuid.UUID) (model.Post, error)
+
+	// FindPostsByAuthorID retrieves one page of the live posts of an author, newest first.
+	// It reports whether more posts exist beyond the page in the read direction.
+	FindPostsByAuthorID(ctx context.Context, authorID uuid.UUID, page pagination.Request) ([]model.Post, bool, error)
+
+	// FindPostsByParentID retrieves every reply to a post, tombstones included
+	FindPostsByParentID(ctx context.Context, id uuid.UUID) ([]model.Post, error)
+
+	// FindRepliesPage retrieves one page of replies to a parent post in the given order.
+	// It reports whether more replies exist beyond the page in the read direction.
+	FindRepliesPage(ctx context.Context, parentID uuid.UUID, order model.SortOrder, page pagination.Request) ([]model.Post, bool, error)
+
+	// SavePost saves a new post, or returns ErrPostExists if its ID is taken
+	SavePost(ctx context.Context, post model.Post) error
+
+	// DeletePost deletes a post with its revisions and reactions
+	DeletePost(ctx context.Context, id uuid.UUID) error
+
+	// UpdatePostContent replaces the content of a live post if it is still at the expected version,
+	// and bumps its version. It reports false when the post is deleted or was edited concurrently.
+	UpdatePostContent(ctx context.Context, id uuid.UUID, version int, content string, editedAt time.Time) (bool, error)
+
+	// TombstonePost strips the content of a live post and marks it deleted, keeping the content
+	// for a restore, records whether a moderator deleted it, and bumps its version.
+	// It reports false when the post does not exist or is already a tombstone.
+	TombstonePost(ctx context.Context, id uuid.UUID, deletedAt time.Time, moderated bool) (bool, error)
+
+	// RestorePost brings back the content of a tombstone deleted at or after the given time,
+	// clearing its moderated flag, and bumps its version. It reports false when no such tombstone exists.
+	RestorePost(ctx context.Context, id uuid.UUID, deletedSince time.Time) (bool, error)
+
+	// PurgeTombstones hard-deletes the tombstones deleted before the given time that have no
+	// replies or shares, live or deleted at or after that time and so still restorable,
+	// with their revisions and reactions. It returns the number of posts deleted.
+	PurgeTombstones(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+	// IncrementCounter increments a counter field of a post, or returns ErrPostNotFound
+	IncrementCounter(ctx context.Context, id uuid.UUID, field string) error
+
+	// DecrementCounter decrements a counter field of a post without going below zero
+	DecrementCounter(ctx context.Context, id uuid.UUID, field string) error
+
+	// SaveRevision saves a previous version of a post, or returns ErrRevisionExists
+	// if that version was already saved
+	SaveRevision(ctx context.Context, revision model.PostRevision) error
+
+	// DeleteRevision removes a revision from the history of a post
+	DeleteRevision(ctx context.Context, id uuid.UUID) error
+
+	// FindRevisions retrieves the revision history of a post, latest first
+	FindRevisions(ctx context.Context, postID uuid.UUID) ([]model.PostRevision, error)
+
+	// AddReaction saves a reaction unless the user already reacted to the post with that kind.
+	// It reports whether the reaction was added.
+	AddReaction(ctx context.Context, reaction model.Reaction) (bool, error)
+
+	// RemoveReaction deletes the reaction of a user to a post with a kind.
+	// It reports whether a reaction was removed.
+	RemoveReaction(ctx context.Context, postID, userID uuid.UUID, kind string) (bool, error)
+
+	// FindUserReactions retrieves the kinds a user reacted to a post with
+	FindUserReactions(ctx context.Context, postID, userID uuid.UUID) ([]string, error)
+
+	// FindReactionsPage retrieves one page of reactions to a post, newest first, optionally of a single kind.
+	// It reports whether more reactions exist beyond the page in the read direction.
+	FindReactionsPage(ctx context.Context, postID uuid.UUID, kind string, page pagination.Request) ([]model.Reaction, bool, error)
+
+	// SaveModerationAction records a moderator action in the moderation log
+	SaveModerationAction(ctx context.Context, action model.ModerationAction) error
+
+	// QueueFeedUpdate records that the feeds must catch up with a post, due right away. An update
+	// already queued for the post is replaced, keeping the highest deleted version.
+	QueueFeedUpdate(ctx context.Context, postID uuid.UUID, deletedVersion int, queuedAt time.Time) error
+
+	// ClaimFeedUpdate takes the feed update due the earliest at now, holding it until leaseUntil
+	// and counting the attempt. It reports false when no update is due.
+	ClaimFeedUpdate(ctx context.Context, now, leaseUntil time.Time) (model.FeedUpdate, bool, error)
+
+	// CompleteFeedUpdate deletes a delivered feed update, unless the post queued it again since
+	CompleteFeedUpdate(ctx context.Context, postID uuid.UUID, queuedAt time.Time) error
+
+	// RescheduleFeedUpdate makes a failed feed update due again at nextAttemptAt, unless the post queued it again since
+	RescheduleFeedUpdate(ctx context.Context, postID uuid.UUID, queuedAt, nextAttemptAt time.Time) error
 }
 
-// Declare a global variable for the singleton instance of PostRepository
-var (
-	postRepositoryInstance *PostRepository
-	once                   sync.Once
+// Counter fields of a post that are updated atomically
+const (
+	RepliesCountField = "replies_count"
+	SharesCountField  = "shares_count"
 )
 
-// NewPostRepository creates a new PostRepository instance if it doesn't exist
-func NewPostRepository(db *mongo.Database) *PostRepository {
-	once.Do(func() {
-		postRepositoryInstance = &PostRepository{
-			Collection: db.Collection("posts"),
-		}
+// scoreField holds the sum of the replies and shares counters, which the top sort ranks by
+const scoreField = "score"
+
+// counterUpdate returns the update adding delta to a counter field, and to the score
+// when the counter is one of those it sums
+func counterUpdate(field string, delta int) bson.M {
+	inc := bson.M{field: delta}
+	if field == RepliesCountField || field == SharesCountField {
+		inc[scoreField] = delta
+	}
+	return bson.M{"$inc": inc}
+}
+
+// ReactionsCountField returns the counter field of a post holding the reactions of a kind
+func ReactionsCountField(kind string) string {
+	return "reactions." + kind
+}
+
+// MongoPostRepository stores posts in MongoDB
+type MongoPostRepository struct {
+	Collection    *mongo.Collection
+	Revisions     *mongo.Collection
+	Reactions     *mongo.Collection
+	ModerationLog *mongo.Collection
+	FeedUpdates   *mongo.Collection
+	transactions  bool // Whether the deployment supports multi-document transactions
+}
+
+// NewMongoPostRepository creates a MongoPostRepository over the collections of db
+func NewMongoPostRepository(db *mongo.Database) *MongoPostRepository {
+	return &MongoPostRepository{
+		Collection:    db.Collection("posts"),
+		Revisions:     db.Collection("post_revisions"),
+		Reactions:     db.Collection("post_reactions"),
+		ModerationLog: db.Collection("moderation_log"),
+		FeedUpdates:   db.Collection("feed_updates"),
+	}
+}
+
+// DetectTransactionSupport checks whether MongoDB runs as a replica set or a sharded cluster,
+// the only deployments that support multi-document transactions, and remembers the answer
+func (r *MongoPostRepository) DetectTransactionSupport(ctx context.Context) (bool, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	err := r.Collection.Database().RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+
+	r.transactions = hello.SetName != "" || hello.Msg == "isdbgrid"
+	return r.transactions, nil
+}
+
+// SupportsTransactions reports whether WithTransaction runs its function in a transaction
+func (r *MongoPostRepository) SupportsTransactions() bool {
+	return r.transactions
+}
+
+// WithTransaction runs fn inside a session and a transaction, retried on transient errors,
+// when the deployment supports them. Otherwise fn runs directly against the database
+// and is responsible for compensating its own partial writes.
+func (r *MongoPostRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !r.transactions {
+		return fn(ctx)
+	}
+
+	session, err := r.Collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
 	})
-	return postRepositoryInstance
+	return err
 }
 
 // FindPostByID retrieves a post by its ID
-func (r *PostRepository) FindPostByID(ctx context.Context, id uuid.UUID) (model.Post, error) {
+func (r *MongoPostRepository) FindPostByID(ctx context.Context, id uuid.UUID) (model.Post, error) {
 	// Filter for finding the post by its ID
 	filter := bson.M{"_id": id}
 
@@ -42,7 +217,7 @@ func (r *PostRepository) FindPostByID(ctx context.Context, id uuid.UUID) (model.
 	err := r.Collection.FindOne(ctx, filter).Decode(&post)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return model.Post{}, errors.New("post not found")
+			return model.Post{}, ErrPostNotFound
 		}
 		return model.Post{}, err
 	}
@@ -50,62 +225,409 @@ func (r *PostRepository) FindPostByID(ctx context.Context, id uuid.UUID) (model.
 	return post, nil
 }
 
-// FindPostsByAuthorID retrieves all posts by a given author ID
-func (r *PostRepository) FindPostsByAuthorID(ctx context.Context, authorID uuid.UUID) ([]model.Post, error) {
-	// Filter for finding posts by author ID
-	filter := bson.M{"author_id": authorID}
+// EnsureIndexes creates the indexes the repository queries rely on, after bringing the
+// score of posts that lack one, or that an older release left behind, in line with their counters
+func (r *MongoPostRepository) EnsureIndexes(ctx context.Context) error {
+	score := bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$" + RepliesCountField, 0}},
+		bson.M{"$ifNull": bson.A{"$" + SharesCountField, 0}},
+	}}
+	_, err := r.Collection.UpdateMany(ctx,
+		bson.M{"$expr": bson.M{"$ne": bson.A{"$" + scoreField, score}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{scoreField: score}}}},
+	)
+	if err != nil {
+		return err
+	}
 
-	cursor, err := r.Collection.Find(ctx, filter)
+	_, err = r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Serves the newest-first author listing at the same cost for every page
+			Keys:    bson.D{{Key: "author_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("author_created_at"),
+		},
+		{
+			// Serves the purger looking for expired tombstones
+			Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetName("deleted_at").SetSparse(true),
+		},
+		{
+			// Serves the chronological replies listing in both directions
+			Keys:    bson.D{{Key: "parent_post_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("parent_created_at"),
+		},
+		{
+			// Serves the top replies listing in both directions
+			Keys:    bson.D{{Key: "parent_post_id", Value: 1}, {Key: scoreField, Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("parent_score_created_at"),
+		},
+		{
+			// Serves the purger looking for restorable shares of expired tombstones
+			Keys:    bson.D{{Key: "original_post_id", Value: 1}},
+			Options: options.Index().SetName("original_post_id").SetSparse(true),
+		},
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer cursor.Close(ctx)
+	_, err = r.FeedUpdates.Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Serves the dispatcher claiming the update due the earliest
+		Keys:    bson.D{{Key: "next_attempt_at", Value: 1}},
+		Options: options.Index().SetName("next_attempt_at"),
+	})
+	if err != nil {
+		return err
+	}
 
-	var posts []model.Post
-	if err := cursor.All(ctx, &posts); err != nil {
-		return nil, err
+	_, err = r.Revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		// One revision per post version, so concurrent edits of the same version conflict
+		Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetName("post_version").SetUnique(true),
+	})
+	if err != nil {
+		return err
 	}
 
-	return posts, nil
+	_, err = r.Reactions.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// One reaction per user and kind, so concurrent reactions are counted once
+			Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "kind", Value: 1}},
+			Options: options.Index().SetName("post_user_kind").SetUnique(true),
+		},
+		{
+			// Serves the newest-first listing of who reacted, optionally filtered by kind
+			Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "kind", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("post_kind_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("post_created_at"),
+		},
+	})
+	return err
+}
+
+// FindPostsByAuthorID retrieves one page of posts by a given author ID, newest first.
+// It reports whether more posts exist beyond the page in the read direction.
+func (r *MongoPostRepository) FindPostsByAuthorID(ctx context.Context, authorID uuid.UUID, page pagination.Request) ([]model.Post, bool, error) {
+	// Filter for finding the posts by author ID that are not tombstones
+	filter := bson.M{"author_id": authorID, "deleted_at": bson.M{"$exists": false}}
+
+	return r.findPage(ctx, filter, model.SortNewest, page)
 }
 
 // SavePost saves a new post to the database
-func (r *PostRepository) SavePost(ctx context.Context, post model.Post) error {
+func (r *MongoPostRepository) SavePost(ctx context.Context, post model.Post) error {
+	post.Score = post.RepliesCount + post.SharesCount
+
 	_, err := r.Collection.InsertOne(ctx, post, options.InsertOne())
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPostExists
+	}
+	return err
+}
+
+// SaveModerationAction records a moderator action in the moderation log
+func (r *MongoPostRepository) SaveModerationAction(ctx context.Context, action model.ModerationAction) error {
+	_, err := r.ModerationLog.InsertOne(ctx, action)
 	return err
 }
 
 // DeletePost deletes a post by its ID
-func (r *PostRepository) DeletePost(ctx context.Context, id uuid.UUID) error {
+func (r *MongoPostRepository) DeletePost(ctx context.Context, id uuid.UUID) error {
 	// Filter for finding the post by its ID
 	filter := bson.M{"_id": id}
 
 	// Attempt to delete the post from the collection
-	_, err := r.Collection.DeleteOne(ctx, filter)
+	if _, err := r.Collection.DeleteOne(ctx, filter); err != nil {
+		return err
+	}
+
+	// Drop the revision history and the reactions along with the post
+	if _, err := r.Revisions.DeleteMany(ctx, bson.M{"post_id": id}); err != nil {
+		return err
+	}
+	_, err := r.Reactions.DeleteMany(ctx, bson.M{"post_id": id})
 	return err
 }
 
-// UpdatePost updates a post in the database
-func (r *PostRepository) UpdatePost(ctx context.Context, post model.Post) error {
-	// Filter for finding the post by its ID
-	filter := bson.M{"_id": post.ID}
+// UpdatePostContent replaces the content of a live post if it is still at the expected version,
+// and bumps its version. It reports false when the post is deleted or was edited concurrently.
+func (r *MongoPostRepository) UpdatePostContent(ctx context.Context, id uuid.UUID, version int, content string, editedAt time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}, "version": version}
+	if version == 0 {
+		// Posts created before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
 
-	// Prepare the update data
 	update := bson.M{
-		"$set": bson.M{
-			"replies_count": post.RepliesCount,
-			"shares_count":  post.SharesCount,
+		"$set": bson.M{"content": content, "edited_at": editedAt},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SaveRevision saves a previous version of a post to its revision history
+func (r *MongoPostRepository) SaveRevision(ctx context.Context, revision model.PostRevision) error {
+	_, err := r.Revisions.InsertOne(ctx, revision)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrRevisionExists
+	}
+	return err
+}
+
+// DeleteRevision removes a revision from the history of a post
+func (r *MongoPostRepository) DeleteRevision(ctx context.Context, id uuid.UUID) error {
+	_, err := r.Revisions.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// FindRevisions retrieves the revision history of a post, latest first
+func (r *MongoPostRepository) FindRevisions(ctx context.Context, postID uuid.UUID) ([]model.PostRevision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := r.Revisions.Find(ctx, bson.M{"post_id": postID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []model.PostRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// nextVersion bumps the version of a post in an update pipeline, counting from 0 for the
+// posts created before versioning, which have no version field
+var nextVersion = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}}
+
+// TombstonePost strips the content of a post and marks it deleted, keeping the content for a restore.
+// It reports false when the post does not exist or is already a tombstone.
+func (r *MongoPostRepository) TombstonePost(ctx context.Context, id uuid.UUID, deletedAt time.Time, moderated bool) (bool, error) {
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+
+	set := bson.M{"deleted_content": "$content", "deleted_at": deletedAt, "version": nextVersion}
+	if moderated {
+		set["moderated"] = true
+	}
+
+	// Move the content aside with an update pipeline so the swap is atomic
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: set}},
+		{{Key: "$unset", Value: "content"}},
+	}
+
+	result, err := r.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// RestorePost brings back the content of a tombstone deleted at or after the given time.
+// It reports false when no such tombstone exists.
+func (r *MongoPostRepository) RestorePost(ctx context.Context, id uuid.UUID, deletedSince time.Time) (bool, error) {
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$gte": deletedSince}}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"content": "$deleted_content", "version": nextVersion}}},
+		{{Key: "$unset", Value: bson.A{"deleted_at", "deleted_content", "moderated"}}},
+	}
+
+	result, err := r.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// PurgeTombstones hard-deletes the tombstones deleted before the given time, with their revisions.
+// Tombstones with restorable replies or shares are kept, as restoring those takes their counters back.
+// Tombstones that still have live replies or shares are kept so threads and reposts don't dangle.
+func (r *MongoPostRepository) PurgeTombstones(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	filter := bson.M{
+		"deleted_at":    bson.M{"$lt": deletedBefore},
+		"replies_count": 0,
+		"shares_count":  0,
+	}
+
+	cursor, err := r.Collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var expired []model.Post
+	if err := cursor.All(ctx, &expired); err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	expiredIDs := make(bson.A, 0, len(expired))
+	for _, post := range expired {
+		expiredIDs = append(expiredIDs, post.ID)
+	}
+
+	// A reply or share is tombstoned before it gives back its counter, so any that left
+	// an expired tombstone at zero is already visible here
+	cursor, err = r.Collection.Find(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"parent_post_id": bson.M{"$in": expiredIDs}},
+			bson.M{"original_post_id": bson.M{"$in": expiredIDs}},
 		},
+		"deleted_at": bson.M{"$gte": deletedBefore},
+	}, options.Find().SetProjection(bson.M{"parent_post_id": 1, "original_post_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var restorable []model.Post
+	if err := cursor.All(ctx, &restorable); err != nil {
+		return 0, err
+	}
+
+	referenced := map[uuid.UUID]bool{}
+	for _, post := range restorable {
+		if post.ParentPostID != nil {
+			referenced[*post.ParentPostID] = true
+		}
+		if post.OriginalPostID != nil {
+			referenced[*post.OriginalPostID] = true
+		}
+	}
+
+	ids := make(bson.A, 0, len(expired))
+	for _, post := range expired {
+		if !referenced[post.ID] {
+			ids = append(ids, post.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// Keep the filter, a tombstone may have been replied to since it was found
+	filter["_id"] = bson.M{"$in": ids}
+	result, err := r.Collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
 	}
 
-	// Attempt to update the post in the collection
-	_, err := r.Collection.UpdateOne(ctx, filter, update)
+	// Revisions and reactions of a tombstone that survived are unreachable until it is restored,
+	// and a restore is no longer possible past the window, so they can go with the others
+	if _, err := r.Revisions.DeleteMany(ctx, bson.M{"post_id": bson.M{"$in": ids}}); err != nil {
+		return 0, err
+	}
+	if _, err := r.Reactions.DeleteMany(ctx, bson.M{"post_id": bson.M{"$in": ids}}); err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// IncrementCounter atomically increments a counter field of a post
+func (r *MongoPostRepository) IncrementCounter(ctx context.Context, id uuid.UUID, field string) error {
+	// Filter for finding the post by its ID
+	filter := bson.M{"_id": id}
+
+	result, err := r.Collection.UpdateOne(ctx, filter, counterUpdate(field, 1))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPostNotFound
+	}
+	return nil
+}
+
+// DecrementCounter atomically decrements a counter field of a post without going below zero
+func (r *MongoPostRepository) DecrementCounter(ctx context.Context, id uuid.UUID, field string) error {
+	// Only match the post while the counter is positive, so it never drops below zero
+	filter := bson.M{"_id": id, field: bson.M{"$gt": 0}}
+
+	_, err := r.Collection.UpdateOne(ctx, filter, counterUpdate(field, -1))
 	return err
 }
 
+// AddReaction saves a reaction unless the user already reacted to the post with that kind.
+// It reports whether the reaction was added.
+func (r *MongoPostRepository) AddReaction(ctx context.Context, reaction model.Reaction) (bool, error) {
+	filter := bson.M{"post_id": reaction.PostID, "user_id": reaction.UserID, "kind": reaction.Kind}
+
+	update := bson.M{"$setOnInsert": bson.M{"_id": reaction.ID, "created_at": reaction.CreatedAt}}
+
+	result, err := r.Reactions.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent upsert inserted the same reaction first
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// RemoveReaction deletes the reaction of a user to a post with a kind.
+// It reports whether a reaction was removed.
+func (r *MongoPostRepository) RemoveReaction(ctx context.Context, postID, userID uuid.UUID, kind string) (bool, error) {
+	filter := bson.M{"post_id": postID, "user_id": userID, "kind": kind}
+
+	result, err := r.Reactions.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// FindUserReactions retrieves the kinds a user reacted to a post with
+func (r *MongoPostRepository) FindUserReactions(ctx context.Context, postID, userID uuid.UUID) ([]string, error) {
+	filter := bson.M{"post_id": postID, "user_id": userID}
+
+	cursor, err := r.Reactions.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reactions []model.Reaction
+	if err := cursor.All(ctx, &reactions); err != nil {
+		return nil, err
+	}
+
+	kinds := make([]string, 0, len(reactions))
+	for _, reaction := range reactions {
+		kinds = append(kinds, reaction.Kind)
+	}
+	return kinds, nil
+}
+
+// FindReactionsPage retrieves one page of reactions to a post, newest first, optionally of a single kind.
+// It reports whether more reactions exist beyond the page in the read direction.
+func (r *MongoPostRepository) FindReactionsPage(ctx context.Context, postID uuid.UUID, kind string, page pagination.Request) ([]model.Reaction, bool, error) {
+	filter := bson.M{"post_id": postID}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: filter}}}
+
+	return aggregatePage[model.Reaction](ctx, r.Reactions, pipeline, []string{"created_at", "_id"}, -1, page)
+}
+
 // FindPostsByParentID retrieves posts by their parent ID (for replies)
-func (r *PostRepository) FindPostsByParentID(ctx context.Context, id uuid.UUID) ([]model.Post, error) {
+func (r *MongoPostRepository) FindPostsByParentID(ctx context.Context, id uuid.UUID) ([]model.Post, error) {
 	// Filter for finding posts where the ParentPostID matches the given parent post ID
 	filter := bson.M{"parent_post_id": id}
 
@@ -123,3 +645,145 @@ func (r *PostRepository) FindPostsByParentID(ctx context.Context, id uuid.UUID)
 
 	return replies, nil
 }
+
+// FindRepliesPage retrieves one page of replies to a parent post in the given order.
+// It reports whether more replies exist beyond the page in the read direction.
+func (r *MongoPostRepository) FindRepliesPage(ctx context.Context, parentID uuid.UUID, order model.SortOrder, page pagination.Request) ([]model.Post, bool, error) {
+	// Filter for finding posts where the ParentPostID matches the given parent post ID
+	filter := bson.M{"parent_post_id": parentID}
+
+	return r.findPage(ctx, filter, order, page)
+}
+
+// findPage runs a keyset-paginated query over the posts matching the filter.
+// Posts are ranked by the sort keys of the order, with created_at and _id breaking ties,
+// so a page past a cursor costs the same as the first one. The top order ranks by the
+// stored score, which the counters keep up to date, so an index can serve it too.
+func (r *MongoPostRepository) findPage(ctx context.Context, filter bson.M, order model.SortOrder, page pagination.Request) ([]model.Post, bool, error) {
+	keys := []string{"created_at", "_id"}
+	if order == model.SortTop {
+		keys = append([]string{scoreField}, keys...)
+	}
+
+	direction := -1
+	if order == model.SortOldest {
+		direction = 1
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: filter}}}
+
+	return aggregatePage[model.Post](ctx, r.Collection, pipeline, keys, direction, page)
+}
+
+// aggregatePage completes the pipeline with the keyset stages of the page and runs it.
+// Documents are sorted by the keys in the listing direction (1 or -1), read in reverse
+// for backward pages. It reports whether more documents exist beyond the page in the read direction.
+func aggregatePage[T any](ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline, keys []string, direction int, page pagination.Request) ([]T, bool, error) {
+	if page.Backward() {
+		direction = -direction
+	}
+
+	// Keep only the documents past the cursor, comparing the sort keys lexicographically
+	if anchor := page.Anchor(); anchor != nil {
+		values := bson.M{scoreField: anchor.Score, "created_at": anchor.CreatedAt, "_id": anchor.ID}
+
+		op := "$gt"
+		if direction == -1 {
+			op = "$lt"
+		}
+
+		clauses := bson.A{}
+		for i, key := range keys {
+			clause := bson.M{key: bson.M{op: values[key]}}
+			for _, prev := range keys[:i] {
+				clause[prev] = values[prev]
+			}
+			clauses = append(clauses, clause)
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"$or": clauses}}})
+	}
+
+	sort := bson.D{}
+	for _, key := range keys {
+		sort = append(sort, bson.E{Key: key, Value: direction})
+	}
+
+	// Fetch one extra document to find out whether another page exists
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: sort}},
+		bson.D{{Key: "$limit", Value: page.Limit + 1}},
+	)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, false, err
+	}
+	defer cursor.Close(ctx)
+
+	items := []T{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(items) > page.Limit
+	if hasMore {
+		items = items[:page.Limit]
+	}
+
+	// Backward pages are read in reverse, put them back in listing order
+	if page.Backward() {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	return items, hasMore, nil
+}
+
+// QueueFeedUpdate records that the feeds must catch up with a post, due right away. An update
+// already queued for the post is replaced, keeping the highest deleted version.
+func (r *MongoPostRepository) QueueFeedUpdate(ctx context.Context, postID uuid.UUID, deletedVersion int, queuedAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"queued_at": queuedAt, "attempts": 0, "next_attempt_at": queuedAt},
+		"$max": bson.M{"deleted_version": deletedVersion},
+	}
+
+	_, err := r.FeedUpdates.UpdateOne(ctx, bson.M{"_id": postID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// ClaimFeedUpdate takes the feed update due the earliest at now, holding it until leaseUntil
+// and counting the attempt. It reports false when no update is due.
+func (r *MongoPostRepository) ClaimFeedUpdate(ctx context.Context, now, leaseUntil time.Time) (model.FeedUpdate, bool, error) {
+	filter := bson.M{"next_attempt_at": bson.M{"$lte": now}}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": leaseUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var claimed model.FeedUpdate
+	err := r.FeedUpdates.FindOneAndUpdate(ctx, filter, update, opts).Decode(&claimed)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.FeedUpdate{}, false, nil
+	}
+	if err != nil {
+		return model.FeedUpdate{}, false, err
+	}
+	return claimed, true, nil
+}
+
+// CompleteFeedUpdate deletes a delivered feed update, unless the post queued it again since
+func (r *MongoPostRepository) CompleteFeedUpdate(ctx context.Context, postID uuid.UUID, queuedAt time.Time) error {
+	_, err := r.FeedUpdates.DeleteOne(ctx, bson.M{"_id": postID, "queued_at": queuedAt})
+	return err
+}
+
+// RescheduleFeedUpdate makes a failed feed update due again at nextAttemptAt, unless the post queued it again since
+func (r *MongoPostRepository) RescheduleFeedUpdate(ctx context.Context, postID uuid.UUID, queuedAt, nextAttemptAt time.Time) error {
+	filter := bson.M{"_id": postID, "queued_at": queuedAt}
+	_, err := r.FeedUpdates.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"next_attempt_at": nextAttemptAt}})
+	return err
+}
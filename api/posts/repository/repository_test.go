@@ -0,0 +1,162 @@
+package repository_test
+
+import (
+	"context"
+	"hornet/api/posts/model"
+	"hornet/api/posts/repository"
+	"hornet/api/posts/repository/repositorytest"
+	"hornet/common/pagination"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoClient connects to the MongoDB at MONGO_URI, and skips the test when MONGO_URI is unset
+func mongoClient(t *testing.T) *mongo.Client {
+	t.Helper()
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Disconnect(context.Background())
+	})
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	return client
+}
+
+// newMongoRepository creates a MongoPostRepository in a database of its own, dropped after the test
+func newMongoRepository(t *testing.T, client *mongo.Client) *repository.MongoPostRepository {
+	t.Helper()
+	ctx := context.Background()
+
+	db := client.Database("hornet_test_" + uuid.NewString()[:8])
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+	})
+
+	repo := repository.NewMongoPostRepository(db)
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("EnsureIndexes: %v", err)
+	}
+	if _, err := repo.DetectTransactionSupport(ctx); err != nil {
+		t.Fatalf("DetectTransactionSupport: %v", err)
+	}
+	return repo
+}
+
+// TestMongoPostRepository runs the repository checks against the MongoDB at MONGO_URI,
+// each in a database of its own that is dropped afterwards. It is skipped when MONGO_URI is unset.
+func TestMongoPostRepository(t *testing.T) {
+	client := mongoClient(t)
+
+	repositorytest.Run(t, func(t *testing.T) repository.PostRepository {
+		return newMongoRepository(t, client)
+	})
+}
+
+// TestMongoPostWithoutVersion checks that posts created before versioning, which have no
+// version field, count from version 0 through deletes, restores and edits
+func TestMongoPostWithoutVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := newMongoRepository(t, mongoClient(t))
+
+	postID := uuid.New()
+	_, err := repo.Collection.InsertOne(ctx, bson.M{
+		"_id":           postID,
+		"content":       "legacy",
+		"author_id":     uuid.New(),
+		"replies_count": 0,
+		"shares_count":  0,
+		"created_at":    time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+
+	deletedAt := time.Now().UTC().Truncate(time.Millisecond)
+	if ok, err := repo.TombstonePost(ctx, postID, deletedAt, false); err != nil || !ok {
+		t.Fatalf("TombstonePost: got %v, %v", ok, err)
+	}
+	if post, err := repo.FindPostByID(ctx, postID); err != nil || post.Version != 1 {
+		t.Fatalf("TombstonePost: got version %d, %v, want 1", post.Version, err)
+	}
+
+	if ok, err := repo.RestorePost(ctx, postID, deletedAt); err != nil || !ok {
+		t.Fatalf("RestorePost: got %v, %v", ok, err)
+	}
+	if ok, err := repo.UpdatePostContent(ctx, postID, 2, "edited", time.Now().UTC()); err != nil || !ok {
+		t.Fatalf("UpdatePostContent after a restore: got %v, %v", ok, err)
+	}
+
+	post, err := repo.FindPostByID(ctx, postID)
+	if err != nil {
+		t.Fatalf("FindPostByID: %v", err)
+	}
+	if post.Version != 3 || post.Content != "edited" {
+		t.Fatalf("UpdatePostContent: got version %d and content %q, want 3 and %q", post.Version, post.Content, "edited")
+	}
+}
+
+// TestMongoPostWithoutScore checks that EnsureIndexes scores the replies stored before the score
+// was, so they rank by their counters in the top order
+func TestMongoPostWithoutScore(t *testing.T) {
+	ctx := context.Background()
+	repo := newMongoRepository(t, mongoClient(t))
+
+	parentID := uuid.New()
+	legacy := func(repliesCount, sharesCount int) uuid.UUID {
+		t.Helper()
+		postID := uuid.New()
+		_, err := repo.Collection.InsertOne(ctx, bson.M{
+			"_id":            postID,
+			"content":        "legacy",
+			"author_id":      uuid.New(),
+			"parent_post_id": parentID,
+			"replies_count":  repliesCount,
+			"shares_count":   sharesCount,
+			"created_at":     time.Now().UTC(),
+		})
+		if err != nil {
+			t.Fatalf("InsertOne: %v", err)
+		}
+		return postID
+	}
+	quiet, shared, replied := legacy(0, 0), legacy(0, 1), legacy(2, 1)
+
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("EnsureIndexes: %v", err)
+	}
+
+	replies, _, err := repo.FindRepliesPage(ctx, parentID, model.SortTop, pagination.Request{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindRepliesPage: %v", err)
+	}
+	want := []uuid.UUID{replied, shared, quiet}
+	if len(replies) != len(want) {
+		t.Fatalf("FindRepliesPage: got %d replies, want %d", len(replies), len(want))
+	}
+	for i, reply := range replies {
+		if reply.ID != want[i] {
+			t.Fatalf("reply %d: got %s, want %s", i, reply.ID, want[i])
+		}
+	}
+	if replies[0].Score != 3 {
+		t.Fatalf("score: got %d, want 3", replies[0].Score)
+	}
+}
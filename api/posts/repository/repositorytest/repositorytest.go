@@ -0,0 +1,550 @@
+// Package repositorytest holds the contract every repository.PostRepository backend must pass.
+//
+// A backend runs it from its own tests, with a constructor returning an empty repository:
+//
+//	func TestMemoryPostRepository(t *testing.T) {
+//		repositorytest.Run(t, func(t *testing.T) repository.PostRepository {
+//			return repository.NewMemoryPostRepository()
+//		})
+//	}
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"hornet/api/posts/model"
+	"hornet/api/posts/repository"
+	"hornet/common/pagination"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Run checks the behavior of the repositories returned by newRepository, one empty repository per subtest
+func Run(t *testing.T, newRepository func(t *testing.T) repository.PostRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo repository.PostRepository)
+	}{
+		{"SaveAndFindPost", testSaveAndFindPost},
+		{"PostsByAuthor", testPostsByAuthor},
+		{"Replies", testReplies},
+		{"UpdatePostContent", testUpdatePostContent},
+		{"TombstoneAndRestore", testTombstoneAndRestore},
+		{"PurgeTombstones", testPurgeTombstones},
+		{"Counters", testCounters},
+		{"Revisions", testRevisions},
+		{"Reactions", testReactions},
+		{"DeletePost", testDeletePost},
+		{"FeedUpdates", testFeedUpdates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepository(t))
+		})
+	}
+}
+
+// baseTime is a creation time every backend stores without losing precision
+var baseTime = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// at returns the time n seconds after baseTime
+func at(n int) time.Time {
+	return baseTime.Add(time.Duration(n) * time.Second)
+}
+
+func newPost(authorID uuid.UUID, createdAt time.Time) model.Post {
+	return model.Post{
+		ID:        uuid.New(),
+		Content:   "content " + createdAt.Format(time.RFC3339),
+		AuthorID:  authorID,
+		CreatedAt: createdAt,
+	}
+}
+
+func newReply(parent model.Post, createdAt time.Time) model.Post {
+	reply := newPost(uuid.New(), createdAt)
+	reply.ParentPostID = &parent.ID
+	return reply
+}
+
+func save(t *testing.T, repo repository.PostRepository, posts ...model.Post) {
+	t.Helper()
+	for _, post := range posts {
+		if err := repo.SavePost(context.Background(), post); err != nil {
+			t.Fatalf("SavePost(%s): %v", post.ID, err)
+		}
+	}
+}
+
+func find(t *testing.T, repo repository.PostRepository, id uuid.UUID) model.Post {
+	t.Helper()
+	post, err := repo.FindPostByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("FindPostByID(%s): %v", id, err)
+	}
+	return post
+}
+
+// expectIDs fails unless posts holds the wanted posts, in order
+func expectIDs(t *testing.T, what string, posts []model.Post, want ...model.Post) {
+	t.Helper()
+	if len(posts) != len(want) {
+		t.Fatalf("%s: got %d posts, want %d", what, len(posts), len(want))
+	}
+	for i := range want {
+		if posts[i].ID != want[i].ID {
+			t.Fatalf("%s: post %d is %s, want %s", what, i, posts[i].ID, want[i].ID)
+		}
+	}
+}
+
+func postCursor(post model.Post) *pagination.Cursor {
+	return &pagination.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+}
+
+func testSaveAndFindPost(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+
+	if _, err := repo.FindPostByID(ctx, uuid.New()); !errors.Is(err, repository.ErrPostNotFound) {
+		t.Fatalf("FindPostByID of a missing post: got %v, want ErrPostNotFound", err)
+	}
+
+	post := newPost(uuid.New(), at(0))
+	post.ViewerReactions = []string{"like"}
+	save(t, repo, post)
+
+	found := find(t, repo, post.ID)
+	if found.Content != post.Content || found.AuthorID != post.AuthorID || !found.CreatedAt.Equal(post.CreatedAt) {
+		t.Fatalf("FindPostByID: got %+v, want %+v", found, post)
+	}
+	if len(found.ViewerReactions) != 0 {
+		t.Fatalf("FindPostByID: viewer reactions %v were stored", found.ViewerReactions)
+	}
+
+	if err := repo.SavePost(ctx, post); !errors.Is(err, repository.ErrPostExists) {
+		t.Fatalf("SavePost of a taken ID: got %v, want ErrPostExists", err)
+	}
+}
+
+func testPostsByAuthor(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+	authorID := uuid.New()
+
+	oldest, middle, newest := newPost(authorID, at(0)), newPost(authorID, at(1)), newPost(authorID, at(2))
+	deleted := newPost(authorID, at(3))
+	save(t, repo, oldest, middle, newest, deleted, newPost(uuid.New(), at(4)))
+	if _, err := repo.TombstonePost(ctx, deleted.ID, at(5), false); err != nil {
+		t.Fatalf("TombstonePost: %v", err)
+	}
+
+	posts, hasMore, err := repo.FindPostsByAuthorID(ctx, authorID, pagination.Request{Limit: 2})
+	if err != nil {
+		t.Fatalf("FindPostsByAuthorID: %v", err)
+	}
+	expectIDs(t, "first page", posts, newest, middle)
+	if !hasMore {
+		t.Fatal("first page: hasMore is false")
+	}
+
+	posts, hasMore, err = repo.FindPostsByAuthorID(ctx, authorID, pagination.Request{Limit: 2, After: postCursor(middle)})
+	if err != nil {
+		t.Fatalf("FindPostsByAuthorID after: %v", err)
+	}
+	expectIDs(t, "second page", posts, oldest)
+	if hasMore {
+		t.Fatal("second page: hasMore is true")
+	}
+
+	posts, hasMore, err = repo.FindPostsByAuthorID(ctx, authorID, pagination.Request{Limit: 1, Before: postCursor(oldest)})
+	if err != nil {
+		t.Fatalf("FindPostsByAuthorID before: %v", err)
+	}
+	expectIDs(t, "backward page", posts, middle)
+	if !hasMore {
+		t.Fatal("backward page: hasMore is false")
+	}
+}
+
+func testReplies(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+
+	parent := newPost(uuid.New(), at(0))
+	first, second, third := newReply(parent, at(1)), newReply(parent, at(2)), newReply(parent, at(3))
+	second.RepliesCount = 2
+	third.SharesCount = 1
+	save(t, repo, parent, first, second, third, newReply(newPost(uuid.New(), at(0)), at(4)))
+
+	tests := []struct {
+		order model.SortOrder
+		want  []model.Post
+	}{
+		{model.SortOldest, []model.Post{first, second, third}},
+		{model.SortNewest, []model.Post{third, second, first}},
+		{model.SortTop, []model.Post{second, third, first}},
+	}
+	for _, tt := range tests {
+		replies, hasMore, err := repo.FindRepliesPage(ctx, parent.ID, tt.order, pagination.Request{Limit: 10})
+		if err != nil {
+			t.Fatalf("FindRepliesPage(%s): %v", tt.order, err)
+		}
+		expectIDs(t, string(tt.order), replies, tt.want...)
+		if hasMore {
+			t.Fatalf("%s: hasMore is true", tt.order)
+		}
+	}
+
+	// Top replies resume after a cursor carrying the score
+	after := &pagination.Cursor{Score: 2, CreatedAt: second.CreatedAt, ID: second.ID}
+	replies, _, err := repo.FindRepliesPage(ctx, parent.ID, model.SortTop, pagination.Request{Limit: 1, After: after})
+	if err != nil {
+		t.Fatalf("FindRepliesPage(top) after: %v", err)
+	}
+	expectIDs(t, "top after cursor", replies, third)
+
+	all, err := repo.FindPostsByParentID(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("FindPostsByParentID: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("FindPostsByParentID: got %d replies, want 3", len(all))
+	}
+}
+
+func testUpdatePostContent(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+
+	post := newPost(uuid.New(), at(0))
+	save(t, repo, post)
+
+	updated, err := repo.UpdatePostContent(ctx, post.ID, 0, "edited", at(1))
+	if err != nil || !updated {
+		t.Fatalf("UpdatePostContent at the current version: got %v, %v", updated, err)
+	}
+
+	found := find(t, repo, post.ID)
+	if found.Content != "edited" || found.Version != 1 || found.EditedAt == nil || !found.EditedAt.Equal(at(1)) {
+		t.Fatalf("UpdatePostContent: got %+v", found)
+	}
+
+	if updated, err := repo.UpdatePostContent(ctx, post.ID, 0, "stale", at(2)); err != nil || updated {
+		t.Fatalf("UpdatePostContent at an outdated version: got %v, %v", updated, err)
+	}
+
+	if _, err := repo.TombstonePost(ctx, post.ID, at(3), false); err != nil {
+		t.Fatalf("TombstonePost: %v", err)
+	}
+	if updated, err := repo.UpdatePostContent(ctx, post.ID, 1, "deleted", at(4)); err != nil || updated {
+		t.Fatalf("UpdatePostContent of a tombstone: got %v, %v", updated, err)
+	}
+}
+
+func testTombstoneAndRestore(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+
+	post := newPost(uuid.New(), at(0))
+	save(t, repo, post)
+
+	if ok, err := repo.TombstonePost(ctx, post.ID, at(10), true); err != nil || !ok {
+		t.Fatalf("TombstonePost: got %v, %v", ok, err)
+	}
+	if ok, err := repo.TombstonePost(ctx, post.ID, at(11), false); err != nil || ok {
+		t.Fatalf("TombstonePost of a tombstone: got %v, %v", ok, err)
+	}
+
+	tombstone := find(t, repo, post.ID)
+	if !tombstone.IsDeleted() || tombstone.Content != "" || !tombstone.Moderated || tombstone.Version != post.Version+1 {
+		t.Fatalf("TombstonePost: got %+v", tombstone)
+	}
+
+	if ok, err := repo.RestorePost(ctx, post.ID, at(11)); err != nil || ok {
+		t.Fatalf("RestorePost past the window: got %v, %v", ok, err)
+	}
+	if ok, err := repo.RestorePost(ctx, post.ID, at(10)); err != nil || !ok {
+		t.Fatalf("RestorePost within the window: got %v, %v", ok, err)
+	}
+
+	restored := find(t, repo, post.ID)
+	if restored.IsDeleted() || restored.Content != post.Content || restored.Moderated || restored.Version != post.Version+2 {
+		t.Fatalf("RestorePost: got %+v", restored)
+	}
+
+	if ok, err := repo.RestorePost(ctx, post.ID, at(0)); err != nil || ok {
+		t.Fatalf("RestorePost of a live post: got %v, %v", ok, err)
+	}
+}
+
+func testPurgeTombstones(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+
+	expired, replied, recent := newPost(uuid.New(), at(0)), newPost(uuid.New(), at(0)), newPost(uuid.New(), at(0))
+	replied.RepliesCount = 1
+
+	// Tombstones whose deleted reply or share is still restorable, and one whose reply expired too
+	threadParent, sharedOriginal, expiredParent := newPost(uuid.New(), at(0)), newPost(uuid.New(), at(0)), newPost(uuid.New(), at(0))
+	restorableReply, expiredReply := newReply(threadParent, at(0)), newReply(expiredParent, at(0))
+	restorableShare := newPost(uuid.New(), at(0))
+	restorableShare.OriginalPostID = &sharedOriginal.ID
+	save(t, repo, expired, replied, recent, threadParent, sharedOriginal, expiredParent, restorableReply, expiredReply, restorableShare)
+
+	deletions := map[uuid.UUID]time.Time{
+		expired.ID:         at(1),
+		replied.ID:         at(1),
+		recent.ID:          at(10),
+		threadParent.ID:    at(1),
+		restorableReply.ID: at(6),
+		sharedOriginal.ID:  at(1),
+		restorableShare.ID: at(6),
+		expiredParent.ID:   at(1),
+		expiredReply.ID:    at(2),
+	}
+	for post, deletedAt := range deletions {
+		if _, err := repo.TombstonePost(ctx, post, deletedAt, false); err != nil {
+			t.Fatalf("TombstonePost: %v", err)
+		}
+	}
+	revision := model.PostRevision{ID: uuid.New(), PostID: expired.ID, Content: "old", CreatedAt: at(0), ReplacedAt: at(1)}
+	if err := repo.SaveRevision(ctx, revision); err != nil {
+		t.Fatalf("SaveRevision: %v", err)
+	}
+
+	purged, err := repo.PurgeTombstones(ctx, at(5))
+	if err != nil {
+		t.Fatalf("PurgeTombstones: %v", err)
+	}
+	if purged != 3 {
+		t.Fatalf("PurgeTombstones: purged %d posts, want 3", purged)
+	}
+
+	for _, id := range []uuid.UUID{expired.ID, expiredParent.ID, expiredReply.ID} {
+		if _, err := repo.FindPostByID(ctx, id); !errors.Is(err, repository.ErrPostNotFound) {
+			t.Fatalf("FindPostByID of a purged post: got %v, want ErrPostNotFound", err)
+		}
+	}
+	for _, id := range []uuid.UUID{replied.ID, recent.ID, threadParent.ID, restorableReply.ID, sharedOriginal.ID, restorableShare.ID} {
+		find(t, repo, id)
+	}
+
+	revisions, err := repo.FindRevisions(ctx, expired.ID)
+	if err != nil || len(revisions) != 0 {
+		t.Fatalf("FindRevisions of a purged post: got %d revisions, %v", len(revisions), err)
+	}
+}
+
+func testCounters(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+
+	if err := repo.IncrementCounter(ctx, uuid.New(), repository.RepliesCountField); !errors.Is(err, repository.ErrPostNotFound) {
+		t.Fatalf("IncrementCounter of a missing post: got %v, want ErrPostNotFound", err)
+	}
+
+	post := newPost(uuid.New(), at(0))
+	save(t, repo, post)
+
+	likes := repository.ReactionsCountField("like")
+	for _, field := range []string{repository.RepliesCountField, repository.RepliesCountField, repository.SharesCountField, likes} {
+		if err := repo.IncrementCounter(ctx, post.ID, field); err != nil {
+			t.Fatalf("IncrementCounter(%s): %v", field, err)
+		}
+	}
+	for _, field := range []string{repository.RepliesCountField, repository.SharesCountField, repository.SharesCountField} {
+		if err := repo.DecrementCounter(ctx, post.ID, field); err != nil {
+			t.Fatalf("DecrementCounter(%s): %v", field, err)
+		}
+	}
+
+	found := find(t, repo, post.ID)
+	if found.RepliesCount != 1 || found.SharesCount != 0 || found.Reactions["like"] != 1 {
+		t.Fatalf("counters: got replies %d, shares %d, likes %d, want 1, 0, 1", found.RepliesCount, found.SharesCount, found.Reactions["like"])
+	}
+	// The shares counter was already at zero, the score only follows the changes that applied
+	if found.Score != 1 {
+		t.Fatalf("score: got %d, want 1", found.Score)
+	}
+}
+
+func testRevisions(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+	postID := uuid.New()
+
+	first := model.PostRevision{ID: uuid.New(), PostID: postID, Version: 0, Content: "first", CreatedAt: at(0), ReplacedAt: at(1)}
+	second := model.PostRevision{ID: uuid.New(), PostID: postID, Version: 1, Content: "second", CreatedAt: at(1), ReplacedAt: at(2)}
+	for _, revision := range []model.PostRevision{first, second} {
+		if err := repo.SaveRevision(ctx, revision); err != nil {
+			t.Fatalf("SaveRevision: %v", err)
+		}
+	}
+
+	conflicting := first
+	conflicting.ID = uuid.New()
+	if err := repo.SaveRevision(ctx, conflicting); !errors.Is(err, repository.ErrRevisionExists) {
+		t.Fatalf("SaveRevision of a saved version: got %v, want ErrRevisionExists", err)
+	}
+
+	revisions, err := repo.FindRevisions(ctx, postID)
+	if err != nil {
+		t.Fatalf("FindRevisions: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].ID != second.ID || revisions[1].ID != first.ID {
+		t.Fatalf("FindRevisions: got %+v, want latest first", revisions)
+	}
+
+	if err := repo.DeleteRevision(ctx, second.ID); err != nil {
+		t.Fatalf("DeleteRevision: %v", err)
+	}
+	revisions, err = repo.FindRevisions(ctx, postID)
+	if err != nil || len(revisions) != 1 {
+		t.Fatalf("FindRevisions after DeleteRevision: got %d revisions, %v", len(revisions), err)
+	}
+}
+
+func testReactions(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+	postID, userID := uuid.New(), uuid.New()
+
+	like := model.Reaction{ID: uuid.New(), PostID: postID, UserID: userID, Kind: "like", CreatedAt: at(0)}
+	love := model.Reaction{ID: uuid.New(), PostID: postID, UserID: userID, Kind: "love", CreatedAt: at(1)}
+	other := model.Reaction{ID: uuid.New(), PostID: postID, UserID: uuid.New(), Kind: "like", CreatedAt: at(2)}
+	for _, reaction := range []model.Reaction{like, love, other} {
+		if added, err := repo.AddReaction(ctx, reaction); err != nil || !added {
+			t.Fatalf("AddReaction(%s): got %v, %v", reaction.Kind, added, err)
+		}
+	}
+
+	duplicate := like
+	duplicate.ID = uuid.New()
+	if added, err := repo.AddReaction(ctx, duplicate); err != nil || added {
+		t.Fatalf("AddReaction of an existing reaction: got %v, %v", added, err)
+	}
+
+	kinds, err := repo.FindUserReactions(ctx, postID, userID)
+	if err != nil || len(kinds) != 2 {
+		t.Fatalf("FindUserReactions: got %v, %v", kinds, err)
+	}
+
+	reactions, hasMore, err := repo.FindReactionsPage(ctx, postID, "", pagination.Request{Limit: 2})
+	if err != nil {
+		t.Fatalf("FindReactionsPage: %v", err)
+	}
+	if len(reactions) != 2 || reactions[0].ID != other.ID || reactions[1].ID != love.ID || !hasMore {
+		t.Fatalf("FindReactionsPage: got %+v, %v, want the 2 newest and more", reactions, hasMore)
+	}
+
+	reactions, _, err = repo.FindReactionsPage(ctx, postID, "like", pagination.Request{Limit: 10})
+	if err != nil || len(reactions) != 2 {
+		t.Fatalf("FindReactionsPage(like): got %d reactions, %v", len(reactions), err)
+	}
+
+	if removed, err := repo.RemoveReaction(ctx, postID, userID, "like"); err != nil || !removed {
+		t.Fatalf("RemoveReaction: got %v, %v", removed, err)
+	}
+	if removed, err := repo.RemoveReaction(ctx, postID, userID, "like"); err != nil || removed {
+		t.Fatalf("RemoveReaction of a removed reaction: got %v, %v", removed, err)
+	}
+}
+
+func testDeletePost(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+
+	post := newPost(uuid.New(), at(0))
+	save(t, repo, post)
+	if err := repo.SaveRevision(ctx, model.PostRevision{ID: uuid.New(), PostID: post.ID, Content: "old", CreatedAt: at(0), ReplacedAt: at(1)}); err != nil {
+		t.Fatalf("SaveRevision: %v", err)
+	}
+	if _, err := repo.AddReaction(ctx, model.Reaction{ID: uuid.New(), PostID: post.ID, UserID: uuid.New(), Kind: "like", CreatedAt: at(1)}); err != nil {
+		t.Fatalf("AddReaction: %v", err)
+	}
+
+	if err := repo.DeletePost(ctx, post.ID); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+
+	if _, err := repo.FindPostByID(ctx, post.ID); !errors.Is(err, repository.ErrPostNotFound) {
+		t.Fatalf("FindPostByID of a deleted post: got %v, want ErrPostNotFound", err)
+	}
+	if revisions, err := repo.FindRevisions(ctx, post.ID); err != nil || len(revisions) != 0 {
+		t.Fatalf("FindRevisions of a deleted post: got %d revisions, %v", len(revisions), err)
+	}
+	if reactions, _, err := repo.FindReactionsPage(ctx, post.ID, "", pagination.Request{Limit: 10}); err != nil || len(reactions) != 0 {
+		t.Fatalf("FindReactionsPage of a deleted post: got %d reactions, %v", len(reactions), err)
+	}
+}
+
+func testFeedUpdates(t *testing.T, repo repository.PostRepository) {
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+
+	claim := func(now, leaseUntil time.Time) (model.FeedUpdate, bool) {
+		t.Helper()
+		update, ok, err := repo.ClaimFeedUpdate(ctx, now, leaseUntil)
+		if err != nil {
+			t.Fatalf("ClaimFeedUpdate: %v", err)
+		}
+		return update, ok
+	}
+
+	if err := repo.QueueFeedUpdate(ctx, first, 0, at(0)); err != nil {
+		t.Fatalf("QueueFeedUpdate: %v", err)
+	}
+	if err := repo.QueueFeedUpdate(ctx, second, 0, at(1)); err != nil {
+		t.Fatalf("QueueFeedUpdate: %v", err)
+	}
+	if _, ok := claim(at(-1), at(10)); ok {
+		t.Fatalf("ClaimFeedUpdate before any update is due: got an update")
+	}
+
+	// A delete queued over a pending update keeps its version, and later writes do not lower it
+	if err := repo.QueueFeedUpdate(ctx, first, 3, at(2)); err != nil {
+		t.Fatalf("QueueFeedUpdate: %v", err)
+	}
+	if err := repo.QueueFeedUpdate(ctx, first, 0, at(3)); err != nil {
+		t.Fatalf("QueueFeedUpdate: %v", err)
+	}
+
+	update, ok := claim(at(5), at(10))
+	if !ok || update.PostID != second || update.Attempts != 1 {
+		t.Fatalf("ClaimFeedUpdate: got %+v, %v, want the first due update after its first attempt", update, ok)
+	}
+	update, ok = claim(at(5), at(10))
+	if !ok || update.PostID != first || update.DeletedVersion != 3 || !update.QueuedAt.Equal(at(3)) {
+		t.Fatalf("ClaimFeedUpdate: got %+v, %v, want the coalesced update at deleted version 3", update, ok)
+	}
+	if _, ok := claim(at(5), at(10)); ok {
+		t.Fatalf("ClaimFeedUpdate while every update is held: got an update")
+	}
+
+	// A failed delivery comes back when it is due again, counting its attempts
+	if err := repo.RescheduleFeedUpdate(ctx, second, at(1), at(20)); err != nil {
+		t.Fatalf("RescheduleFeedUpdate: %v", err)
+	}
+	if err := repo.CompleteFeedUpdate(ctx, first, at(3)); err != nil {
+		t.Fatalf("CompleteFeedUpdate: %v", err)
+	}
+	if _, ok := claim(at(15), at(30)); ok {
+		t.Fatalf("ClaimFeedUpdate before the retry is due: got an update")
+	}
+	update, ok = claim(at(20), at(30))
+	if !ok || update.PostID != second || update.Attempts != 2 {
+		t.Fatalf("ClaimFeedUpdate of the retry: got %+v, %v, want its second attempt", update, ok)
+	}
+
+	// A post written again during the delivery keeps its update for the next one
+	if err := repo.QueueFeedUpdate(ctx, second, 0, at(21)); err != nil {
+		t.Fatalf("QueueFeedUpdate: %v", err)
+	}
+	if err := repo.CompleteFeedUpdate(ctx, second, at(1)); err != nil {
+		t.Fatalf("CompleteFeedUpdate: %v", err)
+	}
+	update, ok = claim(at(21), at(30))
+	if !ok || update.PostID != second || update.Attempts != 1 {
+		t.Fatalf("ClaimFeedUpdate after a write during the delivery: got %+v, %v, want a fresh update", update, ok)
+	}
+	if err := repo.CompleteFeedUpdate(ctx, second, update.QueuedAt); err != nil {
+		t.Fatalf("CompleteFeedUpdate: %v", err)
+	}
+	if _, ok := claim(at(100), at(110)); ok {
+		t.Fatalf("ClaimFeedUpdate after every update completed: got an update")
+	}
+}
@@ -3,14 +3,18 @@ package posts
 import (
 	"hornet/api/posts/handler"
 	"hornet/api/posts/service"
+	"hornet/common/auth"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Router sets up the Gin router with all the routes
-func Router(postService *service.PostService) *gin.Engine {
+func Router(postService *service.PostService, authenticator *auth.Authenticator) *gin.Engine {
 	r := gin.Default()
 
+	// Put the authenticated user, if any, into the context of every request
+	r.Use(authenticator.Middleware())
+
 	// Set the handler with the service layer
 	r.POST("/posts", handler.CreatePost(postService))
 
@@ -23,8 +27,24 @@ func Router(postService *service.PostService) *gin.Engine {
 	// Get replies for a parent post
 	r.GET("/posts/:id/replies", handler.GetReplies(postService))
 
+	// Edit the content of a post by ID
+	r.PATCH("/posts/:id", handler.EditPost(postService))
+
+	// Get the revision history of a post
+	r.GET("/posts/:id/revisions", handler.GetRevisions(postService))
+
+	// React to a post, or withdraw a reaction, once per user and kind
+	r.PUT("/posts/:id/reactions/:kind", handler.AddReaction(postService))
+	r.DELETE("/posts/:id/reactions/:kind", handler.RemoveReaction(postService))
+
+	// Get who reacted to a post
+	r.GET("/posts/:id/reactions", handler.GetReactions(postService))
+
 	// Delete a post by ID
 	r.DELETE("/posts/:id", handler.DeletePost(postService))
 
+	// Restore a deleted post by ID
+	r.POST("/posts/:id/restore", handler.RestorePost(postService))
+
 	return r
 }
@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"hornet/api/posts/model"
 	"hornet/api/posts/repository"
-	"sync"
+	"hornet/common/pagination"
+	"hornet/common/problem"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,62 +17,159 @@ import (
 
 // PostService defines the methods for handling post-related business logic
 type PostService struct {
-	postRepository *repository.PostRepository
+	postRepository  repository.PostRepository
+	blockRepository repository.BlockRepository
+	feedRepository  repository.FeedRepository // Nil when no feed service is configured
+	feedQueued      chan struct{}             // Signals queued feed updates to the dispatcher
+	options         Options
+}
+
+const (
+	// feedUpdateLease is how long a delivery holds a feed update before another may take it over,
+	// longer than the delivery itself may take
+	feedUpdateLease = 2 * repository.FeedRequestTimeout
+
+	// maxFeedRetryDelay caps the backoff between the deliveries of a failing feed update
+	maxFeedRetryDelay = time.Hour
+)
+
+// Options holds the tunable business rules of the PostService
+type Options struct {
+	RestoreWindow  time.Duration // How long a deleted post can be restored before it is purged
+	EditWindow     time.Duration // How long after its creation a post can be edited
+	FeedRetryDelay time.Duration // How long a failed feed update waits before its first retry, doubling after each failure
 }
 
-// Declare a global variable for the singleton instance of PostService
 var (
-	postServiceInstance *PostService
-	once                sync.Once
+	// ErrPostNotFound is returned when acting on a post that does not exist
+	ErrPostNotFound = problem.NotFound("post_not_found", "Post not found")
+
+	// ErrForbidden is returned when the actor is not allowed to act on the post
+	ErrForbidden = problem.Forbidden("forbidden", "You are not allowed to act on this post")
+
+	// ErrReasonRequired is returned when a moderator deletes or restores another user's post without a reason
+	ErrReasonRequired = problem.BadRequest("reason_required", "A reason is required to moderate another user's post")
+
+	// ErrModerated is returned when an author restores a post a moderator deleted
+	ErrModerated = problem.Forbidden("post_moderated", "Posts deleted by a moderator can only be restored by a moderator")
+
+	// ErrHasReplies is returned when deleting a post that has replies without cascading
+	ErrHasReplies = problem.Conflict("post_has_replies", "Post has replies")
+
+	// ErrPostDeleted is returned when acting on a post that is already a tombstone
+	ErrPostDeleted = problem.NotFound("post_deleted", "Post is deleted")
+
+	// ErrNotDeleted is returned when restoring a post that is not a tombstone
+	ErrNotDeleted = problem.Conflict("post_not_deleted", "Post is not deleted")
+
+	// ErrRestoreWindowExpired is returned when restoring a post deleted too long ago
+	ErrRestoreWindowExpired = problem.Conflict("restore_window_expired", "The restore window of this post has expired")
+
+	// ErrEditWindowExpired is returned when editing a post created too long ago
+	ErrEditWindowExpired = problem.Forbidden("edit_window_expired", "The edit window of this post has expired")
+
+	// ErrVersionConflict is returned when an edit is based on an outdated version of the post
+	ErrVersionConflict = problem.Conflict("version_conflict", "Post was modified by another edit")
+
+	// ErrBlocked is returned when replying to or sharing a post whose author blocks the user
+	ErrBlocked = problem.Forbidden("blocked", "You cannot reply to or share this post")
 )
 
-// NewPostService creates a new PostService instance if it doesn't exist
-func NewPostService(postRepository *repository.PostRepository) *PostService {
-	once.Do(func() {
-		postServiceInstance = &PostService{
-			postRepository: postRepository,
-		}
-	})
-	return postServiceInstance
+// newValidationError returns the error for a semantically invalid field of a request
+func newValidationError(field, message string) error {
+	return problem.Validation("invalid_field", field, message)
+}
+
+// NewPostService creates a PostService. feedRepository may be nil, posts are then not pushed to feeds.
+func NewPostService(postRepository repository.PostRepository, blockRepository repository.BlockRepository, feedRepository repository.FeedRepository, options Options) *PostService {
+	return &PostService{
+		postRepository:  postRepository,
+		blockRepository: blockRepository,
+		feedRepository:  feedRepository,
+		feedQueued:      make(chan struct{}, 1),
+		options:         options,
+	}
 }
 
-// GetPost retrieves a post by its ID
-func (s *PostService) GetPost(ctx context.Context, postID uuid.UUID) (model.Post, error) {
+// GetPost retrieves a post by its ID.
+// When a viewer is given, the post carries the kinds of reactions they left on it.
+func (s *PostService) GetPost(ctx context.Context, postID, viewerID uuid.UUID) (model.Post, error) {
 
 	// Fetch the post from the repository
-	post, err := s.postRepository.FindPostByID(ctx, postID)
+	post, err := s.findPost(ctx, postID)
 	if err != nil {
 		return model.Post{}, err
 	}
 
+	if viewerID != uuid.Nil {
+		post.ViewerReactions, err = s.postRepository.FindUserReactions(ctx, post.ID, viewerID)
+		if err != nil {
+			return model.Post{}, fmt.Errorf("failed to fetch reactions of user %s to post %s: %w", viewerID, postID, err)
+		}
+	}
+
 	return post, nil
 }
 
-// GetPostsByAuthor retrieves all posts by a given author
-func (s *PostService) GetPostsByAuthor(ctx context.Context, authorID uuid.UUID) ([]model.Post, error) {
+// GetPostsByAuthor retrieves one page of posts by a given author, newest first
+func (s *PostService) GetPostsByAuthor(ctx context.Context, authorID uuid.UUID, page pagination.Request) (model.PostsPage, error) {
 
-	// Call the repository to fetch all posts with the given ParentPostID
-	posts, err := s.postRepository.FindPostsByAuthorID(ctx, authorID)
+	// Call the repository to fetch the page of posts with the given AuthorID
+	posts, hasMore, err := s.postRepository.FindPostsByAuthorID(ctx, authorID, page)
 	if err != nil {
-		return nil, err
+		return model.PostsPage{}, err
 	}
 
-	return posts, nil
+	return newPostsPage(posts, hasMore, model.SortNewest, page), nil
 }
 
-// GetReplies retrieves all replies for a given parent post
-func (s *PostService) GetReplies(ctx context.Context, parentPostID uuid.UUID) ([]model.Post, error) {
-	// Call the repository to fetch all posts with the given ParentPostID
-	replies, err := s.postRepository.FindPostsByParentID(ctx, parentPostID)
+// GetReplies retrieves one page of replies for a given parent post in the given order
+func (s *PostService) GetReplies(ctx context.Context, parentPostID uuid.UUID, order model.SortOrder, page pagination.Request) (model.PostsPage, error) {
+	// Call the repository to fetch the page of posts with the given ParentPostID
+	replies, hasMore, err := s.postRepository.FindRepliesPage(ctx, parentPostID, order, page)
 	if err != nil {
-		return nil, err
+		return model.PostsPage{}, err
 	}
 
-	return replies, nil
+	return newPostsPage(replies, hasMore, order, page), nil
+}
+
+// newPostsPage wraps a page of posts with the cursors pointing at its neighbours
+func newPostsPage(posts []model.Post, hasMore bool, order model.SortOrder, page pagination.Request) model.PostsPage {
+	next, prev := page.Cursors(len(posts), hasMore, func(i int) pagination.Cursor {
+		cursor := pagination.Cursor{CreatedAt: posts[i].CreatedAt, ID: posts[i].ID}
+		if order == model.SortTop {
+			cursor.Score = posts[i].Score
+		}
+		return cursor
+	})
+
+	return model.PostsPage{
+		Posts:      posts,
+		NextCursor: next,
+		PrevCursor: prev,
+	}
 }
 
 // CreatePost handles the creation of a new post
 func (s *PostService) CreatePost(ctx context.Context, req model.CreatePost) (model.Post, error) {
+	// A post is either a reply or a share, quote-replies are not supported
+	if req.ParentPostID != nil && req.OriginalPostID != nil {
+		return model.Post{}, newValidationError("original_post_id", "cannot be set together with parent_post_id")
+	}
+
+	// Replies and shares must reference a live post whose author does not block the user
+	if req.ParentPostID != nil {
+		if err := s.validateReference(ctx, "parent_post_id", *req.ParentPostID, req.AuthorID); err != nil {
+			return model.Post{}, err
+		}
+	}
+	if req.OriginalPostID != nil {
+		if err := s.validateReference(ctx, "original_post_id", *req.OriginalPostID, req.AuthorID); err != nil {
+			return model.Post{}, err
+		}
+	}
+
 	// Generate a new Post ID
 	postID := uuid.New()
 
@@ -89,159 +190,744 @@ func (s *PostService) CreatePost(ctx context.Context, req model.CreatePost) (mod
 		CreatedAt:      time.Now(),
 	}
 
-	// Insert the post into the database using the repository
-	err := s.postRepository.SavePost(ctx, post)
+	// Save the post, bump the referenced counters and queue the feed update as a single unit
+	err := s.withFeedUpdate(ctx, post, 0, func(ctx context.Context) error {
+		// Insert the post into the database using the repository
+		if err := s.postRepository.SavePost(ctx, post); err != nil {
+			return fmt.Errorf("failed to save post: %w", err)
+		}
+
+		// If it's a reply, increment the replies count on the parent post
+		if req.ParentPostID != nil {
+			if err := s.IncrementRepliesCount(ctx, *req.ParentPostID); err != nil {
+				return s.compensate(ctx, err, func(ctx context.Context) error {
+					return s.postRepository.DeletePost(ctx, post.ID)
+				})
+			}
+		}
+
+		// If it's a shared post, increment the shares count on the original post
+		if req.OriginalPostID != nil {
+			if err := s.IncrementSharesCount(ctx, *req.OriginalPostID); err != nil {
+				return s.compensate(ctx, err, func(ctx context.Context) error {
+					if req.ParentPostID == nil {
+						return nil
+					}
+					return s.DecrementRepliesCount(ctx, *req.ParentPostID)
+				}, func(ctx context.Context) error {
+					return s.postRepository.DeletePost(ctx, post.ID)
+				})
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
 		return model.Post{}, err
 	}
 
-	// If it's a reply, increment the replies count on the parent post
-	if req.ParentPostID != nil {
-		err = s.IncrementRepliesCount(ctx, *req.ParentPostID)
+	return post, nil
+}
+
+// findPost fetches a post, or returns ErrPostNotFound when it does not exist
+func (s *PostService) findPost(ctx context.Context, postID uuid.UUID) (model.Post, error) {
+	post, err := s.postRepository.FindPostByID(ctx, postID)
+	if errors.Is(err, repository.ErrPostNotFound) {
+		return model.Post{}, ErrPostNotFound
+	}
+	if err != nil {
+		return model.Post{}, fmt.Errorf("failed to find post with ID %s: %w", postID, err)
+	}
+	return post, nil
+}
+
+// validateReference checks that the post referenced by a request field exists, is not deleted,
+// and that its author does not block the user referencing it
+func (s *PostService) validateReference(ctx context.Context, field string, postID, userID uuid.UUID) error {
+	post, err := s.postRepository.FindPostByID(ctx, postID)
+	if errors.Is(err, repository.ErrPostNotFound) {
+		return newValidationError(field, "referenced post does not exist")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find post with ID %s: %w", postID, err)
+	}
+
+	if post.IsDeleted() {
+		return newValidationError(field, "referenced post is deleted")
+	}
+
+	if post.AuthorID == userID {
+		return nil
+	}
+	blocked, err := s.blockRepository.IsBlocked(ctx, post.AuthorID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check block by author %s: %w", post.AuthorID, err)
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// compensate undoes the writes already applied when the repository runs without transactions,
+// in the given order, and returns the cause of the failure.
+// Inside a transaction MongoDB rolls those writes back on its own.
+func (s *PostService) compensate(ctx context.Context, cause error, undo ...func(ctx context.Context) error) error {
+	if s.postRepository.SupportsTransactions() {
+		return cause
+	}
+
+	for _, fn := range undo {
+		if err := fn(ctx); err != nil {
+			return fmt.Errorf("%w (compensation failed: %v)", cause, err)
+		}
+	}
+	return cause
+}
+
+// DeletePost handles the deletion of a post on behalf of the actor.
+// Authors may delete their own posts, moderators may delete any post given a reason.
+func (s *PostService) DeletePost(ctx context.Context, actor model.Actor, postID uuid.UUID, opts model.DeleteOptions) error {
+	// Fetch the post to check its ownership
+	post, err := s.findPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	if post.IsDeleted() {
+		return ErrPostDeleted
+	}
+
+	moderated := post.AuthorID != actor.UserID
+	if moderated {
+		if !actor.IsModerator() {
+			return ErrForbidden
+		}
+		if strings.TrimSpace(opts.ModeratorReason) == "" {
+			return ErrReasonRequired
+		}
+	}
+
+	// Delete the reply tree, record the moderation and queue the feed update as a single unit.
+	// The deletion takes the next version, so the feeds retract the post at that version even
+	// once it is gone, and it wins over any publish still in flight. A concurrent edit may have
+	// taken it first, then the feed keeps the tombstone on a tie.
+	err = s.withFeedUpdate(ctx, post, post.Version+1, func(ctx context.Context) error {
+		remove := func(ctx context.Context) error {
+			return s.deletePost(ctx, post, opts)
+		}
+		if opts.Soft {
+			remove = func(ctx context.Context) error {
+				return s.tombstonePost(ctx, post, opts, moderated)
+			}
+		}
+
+		if err := remove(ctx); err != nil {
+			return err
+		}
+
+		if !moderated {
+			return nil
+		}
+
+		// Record who deleted the post and why
+		err := s.postRepository.SaveModerationAction(ctx, model.ModerationAction{
+			ID:          uuid.New(),
+			Action:      "delete",
+			PostID:      post.ID,
+			AuthorID:    post.AuthorID,
+			ModeratorID: actor.UserID,
+			Reason:      opts.ModeratorReason,
+			CreatedAt:   time.Now(),
+		})
 		if err != nil {
-			// Log the error and continue with the successfully created post
-			fmt.Printf("Failed to increment replies count for post %s: %v\n", req.ParentPostID, err)
+			return fmt.Errorf("failed to record moderation of post with ID %s: %v", postID, err)
 		}
+		return nil
+	})
+	return err
+}
+
+// withFeedUpdate runs write in a transaction and queues the update of the feeds it calls for,
+// so a slow or unavailable feed service neither delays nor fails the write, and the update is
+// retried until the feed service has it. Deletes give the version the post takes when deleted.
+// Replies are not part of feeds and queue nothing.
+//
+// Within a transaction the update commits along with the write. Without transactions it is
+// queued before the write, so a failed write at worst sends the post as it is, and queued again
+// after it, so a delivery that read the post in between does not count as the last one.
+func (s *PostService) withFeedUpdate(ctx context.Context, post model.Post, deletedVersion int, write func(ctx context.Context) error) error {
+	if s.feedRepository == nil || post.ParentPostID != nil {
+		return s.postRepository.WithTransaction(ctx, write)
 	}
 
-	// If it's a shared post, increment the shares count on the original post
-	if req.OriginalPostID != nil {
-		err = s.IncrementSharesCount(ctx, *req.OriginalPostID)
+	queue := func(ctx context.Context) error {
+		if err := s.postRepository.QueueFeedUpdate(ctx, post.ID, deletedVersion, time.Now()); err != nil {
+			return fmt.Errorf("failed to queue feed update of post with ID %s: %w", post.ID, err)
+		}
+		return nil
+	}
+
+	err := s.postRepository.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := queue(ctx); err != nil {
+			return err
+		}
+		return write(ctx)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !s.postRepository.SupportsTransactions() {
+		if err := queue(ctx); err != nil {
+			log.Printf("%v, the feeds may miss the latest write", err)
+		}
+	}
+
+	// Wake the dispatcher unless it is already due to run
+	select {
+	case s.feedQueued <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// FeedUpdatesQueued signals that feed updates were queued since DeliverFeedUpdates last ran
+func (s *PostService) FeedUpdatesQueued() <-chan struct{} {
+	return s.feedQueued
+}
+
+// DeliverFeedUpdates sends the due feed updates to the feed service and returns how many it delivered.
+// Each update sends the post as it is now, so it carries every write queued before it.
+// A failed update is retried later, waiting twice as long after each failure, and ends
+// the run so an unavailable feed service is not called for every queued update.
+func (s *PostService) DeliverFeedUpdates(ctx context.Context) (int, error) {
+	if s.feedRepository == nil {
+		return 0, nil
+	}
+
+	delivered := 0
+	for {
+		now := time.Now()
+		update, ok, err := s.postRepository.ClaimFeedUpdate(ctx, now, now.Add(feedUpdateLease))
 		if err != nil {
-			// Log the error and continue with the successfully created post
-			fmt.Printf("Failed to increment shares count for post %s: %v\n", req.OriginalPostID, err)
+			return delivered, fmt.Errorf("failed to claim a feed update: %w", err)
+		}
+		if !ok {
+			return delivered, nil
+		}
+
+		if err := s.deliverFeedUpdate(ctx, update); err != nil {
+			err = fmt.Errorf("failed to update feeds for post %s (attempt %d): %w", update.PostID, update.Attempts, err)
+			retryAt := time.Now().Add(s.feedRetryDelay(update.Attempts))
+			if rerr := s.postRepository.RescheduleFeedUpdate(ctx, update.PostID, update.QueuedAt, retryAt); rerr != nil {
+				return delivered, fmt.Errorf("%w (rescheduling failed: %v)", err, rerr)
+			}
+			return delivered, err
+		}
+
+		if err := s.postRepository.CompleteFeedUpdate(ctx, update.PostID, update.QueuedAt); err != nil {
+			return delivered, fmt.Errorf("failed to complete feed update of post %s: %w", update.PostID, err)
 		}
+		delivered++
 	}
-	return post, nil
 }
 
-// DeletePost handles the deletion of a post
-func (s *PostService) DeletePost(ctx context.Context, postID uuid.UUID, params ...string) error {
-	// Fetch the post to be deleted
-	post, err := s.postRepository.FindPostByID(ctx, postID)
+// deliverFeedUpdate sends the current state of a post to the feed service: the post itself,
+// or its retraction at the version it was deleted at once it is a tombstone or gone
+func (s *PostService) deliverFeedUpdate(ctx context.Context, update model.FeedUpdate) error {
+	ctx, cancel := context.WithTimeout(ctx, repository.FeedRequestTimeout)
+	defer cancel()
+
+	post, err := s.postRepository.FindPostByID(ctx, update.PostID)
+	if errors.Is(err, repository.ErrPostNotFound) {
+		return s.feedRepository.RetractPost(ctx, update.PostID, update.DeletedVersion)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to find post with ID %s: %v", postID, err)
+		return err
+	}
+
+	if post.IsDeleted() {
+		return s.feedRepository.RetractPost(ctx, post.ID, post.Version)
 	}
+	return s.feedRepository.PublishPost(ctx, post)
+}
+
+// feedRetryDelay returns how long a feed update waits after its given failed attempt
+func (s *PostService) feedRetryDelay(attempts int) time.Duration {
+	delay := s.options.FeedRetryDelay
+	for i := 1; i < attempts && delay < maxFeedRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxFeedRetryDelay)
+}
 
-	err = s.postRepository.DeletePost(ctx, post.ID)
+// deletePost deletes a post, along with its reply tree when cascading.
+//
+// Only counters of posts outside the deleted tree are decremented: the parent of the post,
+// and the originals of any shared post in the tree. Tombstones already gave their counts back.
+//
+// Without transaction support the deletion cannot be rolled back, so it runs leaves first,
+// adjusting counters as each post goes. An interrupted deletion therefore never leaves
+// replies whose parent is gone, and retrying it deletes whatever remains of the tree.
+func (s *PostService) deletePost(ctx context.Context, post model.Post, opts model.DeleteOptions) error {
+	replies, err := s.collectReplies(ctx, post.ID)
 	if err != nil {
-		return fmt.Errorf("failed to delete post with ID %s: %v", postID, err)
+		return err
 	}
 
-	if post.RepliesCount > 0 {
-		// Fetch all replies for the post
-		replies, err := s.GetReplies(ctx, post.ID)
-		if err != nil {
-			return fmt.Errorf("failed to fetch replies for post with ID %s: %v", postID, err)
+	if len(replies) > 0 && !opts.Cascade {
+		return ErrHasReplies
+	}
+
+	// The tree in breadth-first order, so every reply comes after its parent
+	tree := append([]model.Post{post}, replies...)
+
+	deleted := make(map[uuid.UUID]bool, len(tree))
+	for _, p := range tree {
+		deleted[p.ID] = true
+	}
+
+	// Walk the tree backward to delete replies before the posts they answer
+	for i := len(tree) - 1; i >= 0; i-- {
+		p := tree[i]
+
+		if err := s.postRepository.DeletePost(ctx, p.ID); err != nil {
+			return fmt.Errorf("failed to delete post with ID %s: %v", p.ID, err)
 		}
 
-		// Delete all replies
-		for _, reply := range replies {
-			err = s.DeletePost(ctx, reply.ID, "cascade")
-			if err != nil {
-				// Log the error and continue with the successfully deleted post
-				fmt.Printf("Failed to delete reply %s: %v\n", reply.ID, err)
+		if p.IsDeleted() {
+			continue
+		}
+
+		// If it's a reply to a post that survives, decrement the replies count on the parent post
+		if p.ParentPostID != nil && !deleted[*p.ParentPostID] {
+			if err := s.DecrementRepliesCount(ctx, *p.ParentPostID); err != nil {
+				return err
+			}
+		}
+
+		// If it's a shared post, decrement the shares count on the original post
+		if p.OriginalPostID != nil && !deleted[*p.OriginalPostID] {
+			if err := s.DecrementSharesCount(ctx, *p.OriginalPostID); err != nil {
+				return err
 			}
 		}
 	}
 
-	// If it's a reply, decrement the replies count on the parent post if not deleted with cascade
-	if post.ParentPostID != nil && len(params) > 0 && params[0] != "cascade" {
-		err = s.DecrementRepliesCount(ctx, *post.ParentPostID)
+	return nil
+}
+
+// tombstonePost turns a post into a tombstone, along with its reply tree when cascading.
+// Replies left in place keep pointing at the tombstone, which renders as "this post was deleted".
+// Tombstones left by a moderator are marked so their authors cannot restore them.
+//
+// Counters only count live posts, so every new tombstone gives back its reply to its parent
+// and its share to its original, even when those are tombstones themselves.
+// A tombstone can then be purged once the restore window ends and it has no live or restorable replies or shares.
+func (s *PostService) tombstonePost(ctx context.Context, post model.Post, opts model.DeleteOptions, moderated bool) error {
+	tree := []model.Post{post}
+	if opts.Cascade {
+		replies, err := s.collectReplies(ctx, post.ID)
 		if err != nil {
-			// Log the error and continue with the successfully deleted post
-			fmt.Printf("Failed to decrement replies count for post %s: %v\n", post.ParentPostID, err)
+			return err
 		}
+		tree = append(tree, replies...)
 	}
 
-	// If it's a shared post, decrement the shares count on the original post
-	if post.OriginalPostID != nil {
-		err = s.DecrementSharesCount(ctx, *post.OriginalPostID)
+	deletedAt := time.Now()
+
+	// Walk the tree backward, like deletePost, so an interrupted deletion can be retried
+	for i := len(tree) - 1; i >= 0; i-- {
+		p := tree[i]
+		if p.IsDeleted() {
+			continue
+		}
+
+		tombstoned, err := s.postRepository.TombstonePost(ctx, p.ID, deletedAt, moderated)
 		if err != nil {
-			// Log the error and continue with the successfully deleted post
-			fmt.Printf("Failed to decrement shares count for post %s: %v\n", post.OriginalPostID, err)
+			return fmt.Errorf("failed to delete post with ID %s: %v", p.ID, err)
+		}
+		if !tombstoned {
+			// Deleted concurrently, its counters were given back by that deletion
+			continue
+		}
+
+		// If it's a reply, decrement the replies count on the parent post
+		if p.ParentPostID != nil {
+			if err := s.DecrementRepliesCount(ctx, *p.ParentPostID); err != nil {
+				return err
+			}
+		}
+
+		// If it's a shared post, decrement the shares count on the original post
+		if p.OriginalPostID != nil {
+			if err := s.DecrementSharesCount(ctx, *p.OriginalPostID); err != nil {
+				return err
+			}
 		}
 	}
+
 	return nil
 }
 
-// IncrementRepliesCount increments the replies count for a given post
-func (s *PostService) IncrementRepliesCount(ctx context.Context, postID uuid.UUID) error {
-	// Fetch the parent post
-	post, err := s.postRepository.FindPostByID(ctx, postID)
+// EditPost replaces the content of a post on behalf of its author, within the edit window.
+// The edit must be based on the current version of the post, and the replaced content
+// is kept in the revision history.
+func (s *PostService) EditPost(ctx context.Context, actor model.Actor, postID uuid.UUID, req model.EditPost) (model.Post, error) {
+	post, err := s.findPost(ctx, postID)
 	if err != nil {
-		return fmt.Errorf("failed to find post with ID %s: %v", postID, err)
+		return model.Post{}, err
+	}
+
+	if post.IsDeleted() {
+		return model.Post{}, ErrPostDeleted
+	}
+
+	if post.AuthorID != actor.UserID {
+		return model.Post{}, ErrForbidden
+	}
+
+	if time.Since(post.CreatedAt) > s.options.EditWindow {
+		return model.Post{}, ErrEditWindowExpired
+	}
+
+	if *req.Version != post.Version {
+		return model.Post{}, ErrVersionConflict
 	}
 
-	// Increment the replies count
-	post.RepliesCount++
+	// The replaced content was written at the last edit, or at creation
+	writtenAt := post.CreatedAt
+	if post.EditedAt != nil {
+		writtenAt = *post.EditedAt
+	}
+
+	revision := model.PostRevision{
+		ID:         uuid.New(),
+		PostID:     post.ID,
+		Version:    post.Version,
+		Content:    post.Content,
+		CreatedAt:  writtenAt,
+		ReplacedAt: time.Now(),
+	}
+
+	// Save the revision and the new content, and queue the feed update, as a single unit
+	err = s.withFeedUpdate(ctx, post, 0, func(ctx context.Context) error {
+		// The revision is unique per version, so it also claims the version against concurrent edits
+		err := s.postRepository.SaveRevision(ctx, revision)
+		if errors.Is(err, repository.ErrRevisionExists) {
+			return ErrVersionConflict
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save revision of post with ID %s: %v", postID, err)
+		}
+
+		updated, err := s.postRepository.UpdatePostContent(ctx, post.ID, post.Version, *req.Content, revision.ReplacedAt)
+		if err == nil && !updated {
+			err = ErrVersionConflict
+		}
+		if err != nil {
+			return s.compensate(ctx, err, func(ctx context.Context) error {
+				return s.postRepository.DeleteRevision(ctx, revision.ID)
+			})
+		}
 
-	// Save the updated post back to the repository
-	err = s.postRepository.UpdatePost(ctx, post)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update replies count for post with ID %s: %v", postID, err)
+		return model.Post{}, err
 	}
 
-	return nil
+	return s.postRepository.FindPostByID(ctx, post.ID)
 }
 
-// DecrementRepliesCount decrements the replies count for a given post
-func (s *PostService) DecrementRepliesCount(ctx context.Context, postID uuid.UUID) error {
-	// Fetch the parent post
-	post, err := s.postRepository.FindPostByID(ctx, postID)
+// GetRevisions retrieves the previous versions of a post, latest first
+func (s *PostService) GetRevisions(ctx context.Context, postID uuid.UUID) ([]model.PostRevision, error) {
+	post, err := s.findPost(ctx, postID)
 	if err != nil {
-		return fmt.Errorf("failed to find post with ID %s: %v", postID, err)
+		return nil, err
 	}
 
-	// Decrement the replies count
-	if post.RepliesCount > 0 {
-		post.RepliesCount--
+	if post.IsDeleted() {
+		return nil, ErrPostDeleted
 	}
 
-	// Save the updated post back to the repository
-	err = s.postRepository.UpdatePost(ctx, post)
+	revisions, err := s.postRepository.FindRevisions(ctx, post.ID)
 	if err != nil {
-		return fmt.Errorf("failed to update replies count for post with ID %s: %v", postID, err)
+		return nil, fmt.Errorf("failed to fetch revisions for post with ID %s: %v", postID, err)
 	}
 
-	return nil
+	return revisions, nil
 }
 
-// IncrementRepliesCount increments the shares count for a given post
-func (s *PostService) IncrementSharesCount(ctx context.Context, postID uuid.UUID) error {
-	// Fetch the parent post
-	post, err := s.postRepository.FindPostByID(ctx, postID)
+// AddReaction records the reaction of a user to a post. Reacting twice with the same kind is a no-op.
+func (s *PostService) AddReaction(ctx context.Context, userID, postID uuid.UUID, kind string) error {
+	post, err := s.findReactablePost(ctx, postID, kind)
 	if err != nil {
-		return fmt.Errorf("failed to find post with ID %s: %v", postID, err)
+		return err
+	}
+
+	reaction := model.Reaction{
+		ID:        uuid.New(),
+		PostID:    post.ID,
+		UserID:    userID,
+		Kind:      kind,
+		CreatedAt: time.Now(),
 	}
 
-	// Increment the shares count
-	post.SharesCount++
+	// Save the reaction and count it as a single unit
+	return s.postRepository.WithTransaction(ctx, func(ctx context.Context) error {
+		added, err := s.postRepository.AddReaction(ctx, reaction)
+		if err != nil {
+			return fmt.Errorf("failed to add reaction to post with ID %s: %v", postID, err)
+		}
+		if !added {
+			// Only the request that inserted the reaction counts it
+			return nil
+		}
+
+		err = s.postRepository.IncrementCounter(ctx, post.ID, repository.ReactionsCountField(kind))
+		if err != nil {
+			return s.compensate(ctx, err, func(ctx context.Context) error {
+				_, err := s.postRepository.RemoveReaction(ctx, post.ID, userID, kind)
+				return err
+			})
+		}
+		return nil
+	})
+}
 
-	// Save the updated post back to the repository
-	err = s.postRepository.UpdatePost(ctx, post)
+// RemoveReaction withdraws the reaction of a user to a post. Removing a missing reaction is a no-op.
+func (s *PostService) RemoveReaction(ctx context.Context, userID, postID uuid.UUID, kind string) error {
+	post, err := s.findReactablePost(ctx, postID, kind)
 	if err != nil {
-		return fmt.Errorf("failed to update shares count for post with ID %s: %v", postID, err)
+		return err
+	}
+
+	// Delete the reaction and uncount it as a single unit
+	return s.postRepository.WithTransaction(ctx, func(ctx context.Context) error {
+		removed, err := s.postRepository.RemoveReaction(ctx, post.ID, userID, kind)
+		if err != nil {
+			return fmt.Errorf("failed to remove reaction from post with ID %s: %v", postID, err)
+		}
+		if !removed {
+			// Only the request that deleted the reaction uncounts it
+			return nil
+		}
+
+		err = s.postRepository.DecrementCounter(ctx, post.ID, repository.ReactionsCountField(kind))
+		if err != nil {
+			return s.compensate(ctx, err, func(ctx context.Context) error {
+				_, err := s.postRepository.AddReaction(ctx, model.Reaction{
+					ID:        uuid.New(),
+					PostID:    post.ID,
+					UserID:    userID,
+					Kind:      kind,
+					CreatedAt: time.Now(),
+				})
+				return err
+			})
+		}
+		return nil
+	})
+}
+
+// GetReactions retrieves one page of the reactions to a post, newest first, optionally of a single kind
+func (s *PostService) GetReactions(ctx context.Context, postID uuid.UUID, kind string, page pagination.Request) (model.ReactionsPage, error) {
+	if kind != "" && !model.ValidReactionKind(kind) {
+		return model.ReactionsPage{}, newValidationError("kind", "unknown reaction kind")
+	}
+
+	reactions, hasMore, err := s.postRepository.FindReactionsPage(ctx, postID, kind, page)
+	if err != nil {
+		return model.ReactionsPage{}, fmt.Errorf("failed to fetch reactions for post with ID %s: %w", postID, err)
+	}
+
+	next, prev := page.Cursors(len(reactions), hasMore, func(i int) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: reactions[i].CreatedAt, ID: reactions[i].ID}
+	})
+
+	return model.ReactionsPage{
+		Reactions:  reactions,
+		NextCursor: next,
+		PrevCursor: prev,
+	}, nil
+}
+
+// findReactablePost checks the reaction kind and fetches the live post it targets
+func (s *PostService) findReactablePost(ctx context.Context, postID uuid.UUID, kind string) (model.Post, error) {
+	if !model.ValidReactionKind(kind) {
+		return model.Post{}, newValidationError("kind", "unknown reaction kind")
+	}
+
+	post, err := s.findPost(ctx, postID)
+	if err != nil {
+		return model.Post{}, err
+	}
+
+	if post.IsDeleted() {
+		return model.Post{}, ErrPostDeleted
+	}
+	return post, nil
+}
+
+// RestorePost brings a tombstone back to life within the restore window. Authors may restore
+// the posts they deleted themselves, moderators may restore any post given a reason.
+func (s *PostService) RestorePost(ctx context.Context, actor model.Actor, postID uuid.UUID, moderatorReason string) (model.Post, error) {
+	post, err := s.findPost(ctx, postID)
+	if err != nil {
+		return model.Post{}, err
+	}
+
+	if !post.IsDeleted() {
+		return model.Post{}, ErrNotDeleted
+	}
+
+	moderated := post.Moderated || post.AuthorID != actor.UserID
+	if moderated {
+		if !actor.IsModerator() {
+			if post.AuthorID == actor.UserID {
+				return model.Post{}, ErrModerated
+			}
+			return model.Post{}, ErrForbidden
+		}
+		if strings.TrimSpace(moderatorReason) == "" {
+			return model.Post{}, ErrReasonRequired
+		}
+	}
+
+	deletedSince := time.Now().Add(-s.options.RestoreWindow)
+	if post.DeletedAt.Before(deletedSince) {
+		return model.Post{}, ErrRestoreWindowExpired
+	}
+
+	// Restore the content, take the counters back and queue the feed update as a single unit
+	err = s.withFeedUpdate(ctx, post, 0, func(ctx context.Context) error {
+		restored, err := s.postRepository.RestorePost(ctx, post.ID, deletedSince)
+		if err != nil {
+			return fmt.Errorf("failed to restore post with ID %s: %v", postID, err)
+		}
+		if !restored {
+			// Restored or purged concurrently
+			return ErrNotDeleted
+		}
+
+		retombstone := func(ctx context.Context) error {
+			_, err := s.postRepository.TombstonePost(ctx, post.ID, *post.DeletedAt, post.Moderated)
+			return err
+		}
+
+		// If it's a reply, increment the replies count on the parent post
+		if post.ParentPostID != nil {
+			if err := s.IncrementRepliesCount(ctx, *post.ParentPostID); err != nil {
+				return s.compensate(ctx, err, retombstone)
+			}
+		}
+
+		// If it's a shared post, increment the shares count on the original post
+		if post.OriginalPostID != nil {
+			if err := s.IncrementSharesCount(ctx, *post.OriginalPostID); err != nil {
+				return s.compensate(ctx, err, func(ctx context.Context) error {
+					if post.ParentPostID == nil {
+						return nil
+					}
+					return s.DecrementRepliesCount(ctx, *post.ParentPostID)
+				}, retombstone)
+			}
+		}
+
+		if !moderated {
+			return nil
+		}
+
+		// Record who restored the post and why
+		err = s.postRepository.SaveModerationAction(ctx, model.ModerationAction{
+			ID:          uuid.New(),
+			Action:      "restore",
+			PostID:      post.ID,
+			AuthorID:    post.AuthorID,
+			ModeratorID: actor.UserID,
+			Reason:      moderatorReason,
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record moderation of post with ID %s: %v", postID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return model.Post{}, err
+	}
+
+	return s.postRepository.FindPostByID(ctx, post.ID)
+}
+
+// PurgeDeletedPosts hard-deletes the tombstones whose restore window has passed
+func (s *PostService) PurgeDeletedPosts(ctx context.Context) (int64, error) {
+	purged, err := s.postRepository.PurgeTombstones(ctx, time.Now().Add(-s.options.RestoreWindow))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted posts: %w", err)
+	}
+
+	return purged, nil
+}
+
+// collectReplies returns every reply under a post in breadth-first order, without trusting replies_count
+func (s *PostService) collectReplies(ctx context.Context, postID uuid.UUID) ([]model.Post, error) {
+	var tree []model.Post
+
+	queue := []uuid.UUID{postID}
+	for len(queue) > 0 {
+		parentID := queue[0]
+		queue = queue[1:]
+
+		replies, err := s.postRepository.FindPostsByParentID(ctx, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch replies for post with ID %s: %v", parentID, err)
+		}
+
+		for _, reply := range replies {
+			tree = append(tree, reply)
+			queue = append(queue, reply.ID)
+		}
+	}
+
+	return tree, nil
+}
+
+// IncrementRepliesCount increments the replies count for a given post
+func (s *PostService) IncrementRepliesCount(ctx context.Context, postID uuid.UUID) error {
+	err := s.postRepository.IncrementCounter(ctx, postID, repository.RepliesCountField)
+	if err != nil {
+		return fmt.Errorf("failed to update replies count for post with ID %s: %v", postID, err)
 	}
 
 	return nil
 }
 
-// DecrementSharesCount decrements the shares count for a given post
-func (s *PostService) DecrementSharesCount(ctx context.Context, postID uuid.UUID) error {
-	// Fetch the parent post
-	post, err := s.postRepository.FindPostByID(ctx, postID)
+// DecrementRepliesCount decrements the replies count for a given post
+func (s *PostService) DecrementRepliesCount(ctx context.Context, postID uuid.UUID) error {
+	err := s.postRepository.DecrementCounter(ctx, postID, repository.RepliesCountField)
 	if err != nil {
-		return fmt.Errorf("failed to find post with ID %s: %v", postID, err)
+		return fmt.Errorf("failed to update replies count for post with ID %s: %v", postID, err)
 	}
 
-	// Decrement the shares count
-	if post.SharesCount > 0 {
-		post.SharesCount--
+	return nil
+}
+
+// IncrementSharesCount increments the shares count for a given post
+func (s *PostService) IncrementSharesCount(ctx context.Context, postID uuid.UUID) error {
+	err := s.postRepository.IncrementCounter(ctx, postID, repository.SharesCountField)
+	if err != nil {
+		return fmt.Errorf("failed to update shares count for post with ID %s: %v", postID, err)
 	}
 
-	// Save the updated post back to the repository
-	err = s.postRepository.UpdatePost(ctx, post)
+	return nil
+}
+
+// DecrementSharesCount decrements the shares count for a given post
+func (s *PostService) DecrementSharesCount(ctx context.Context, postID uuid.UUID) error {
+	err := s.postRepository.DecrementCounter(ctx, postID, repository.SharesCountField)
 	if err != nil {
 		return fmt.Errorf("failed to update shares count for post with ID %s: %v", postID, err)
 	}
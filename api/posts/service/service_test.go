@@ -0,0 +1,356 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"hornet/api/posts/model"
+	"hornet/api/posts/repository"
+	"hornet/api/posts/service"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newService creates a PostService over empty in-memory repositories, without a feed
+func newService(t *testing.T) (*service.PostService, *repository.MemoryPostRepository) {
+	t.Helper()
+	postRepository := repository.NewMemoryPostRepository()
+	postService := service.NewPostService(postRepository, repository.NewMemoryBlockRepository(), nil, service.Options{
+		RestoreWindow: time.Hour,
+		EditWindow:    time.Hour,
+	})
+	return postService, postRepository
+}
+
+// createPost creates a post through the service, as a reply or share when the IDs are set
+func createPost(t *testing.T, postService *service.PostService, authorID uuid.UUID, parentPostID, originalPostID *uuid.UUID) model.Post {
+	t.Helper()
+	content := "content"
+	post, err := postService.CreatePost(context.Background(), model.CreatePost{
+		Content:        &content,
+		ParentPostID:   parentPostID,
+		OriginalPostID: originalPostID,
+		AuthorID:       authorID,
+	})
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	return post
+}
+
+func findPost(t *testing.T, postRepository repository.PostRepository, postID uuid.UUID) model.Post {
+	t.Helper()
+	post, err := postRepository.FindPostByID(context.Background(), postID)
+	if err != nil {
+		t.Fatalf("FindPostByID(%s): %v", postID, err)
+	}
+	return post
+}
+
+func TestConcurrentRepliesCount(t *testing.T) {
+	const replies = 300
+
+	postService, postRepository := newService(t)
+	parent := createPost(t, postService, uuid.New(), nil, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, replies)
+	for i := 0; i < replies; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content := "reply"
+			_, err := postService.CreatePost(context.Background(), model.CreatePost{
+				Content:      &content,
+				ParentPostID: &parent.ID,
+				AuthorID:     uuid.New(),
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("CreatePost: %v", err)
+		}
+	}
+
+	if got := findPost(t, postRepository, parent.ID).RepliesCount; got != replies {
+		t.Fatalf("replies_count: got %d, want %d", got, replies)
+	}
+}
+
+func TestDeletePostCounters(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	actor := model.Actor{UserID: authorID}
+
+	// Every case deletes a target post, then expects one surviving reply on its parent
+	// and one surviving share on the original. Counters never go below zero, so a
+	// double decrement would show as 0 and a missing one as 2.
+	cases := []struct {
+		name    string
+		cascade bool
+		setup   func(t *testing.T, postService *service.PostService) (target, parentID, originalID uuid.UUID)
+	}{
+		{
+			name: "direct reply",
+			setup: func(t *testing.T, postService *service.PostService) (uuid.UUID, uuid.UUID, uuid.UUID) {
+				root := createPost(t, postService, authorID, nil, nil)
+				createPost(t, postService, authorID, &root.ID, nil)
+				createPost(t, postService, authorID, nil, &root.ID)
+				reply := createPost(t, postService, authorID, &root.ID, nil)
+				return reply.ID, root.ID, root.ID
+			},
+		},
+		{
+			name: "share",
+			setup: func(t *testing.T, postService *service.PostService) (uuid.UUID, uuid.UUID, uuid.UUID) {
+				root := createPost(t, postService, authorID, nil, nil)
+				createPost(t, postService, authorID, &root.ID, nil)
+				createPost(t, postService, authorID, nil, &root.ID)
+				share := createPost(t, postService, authorID, nil, &root.ID)
+				return share.ID, root.ID, root.ID
+			},
+		},
+		{
+			name:    "cascade tree",
+			cascade: true,
+			setup: func(t *testing.T, postService *service.PostService) (uuid.UUID, uuid.UUID, uuid.UUID) {
+				root := createPost(t, postService, authorID, nil, nil)
+				createPost(t, postService, authorID, &root.ID, nil)
+				createPost(t, postService, authorID, nil, &root.ID)
+				reply := createPost(t, postService, authorID, &root.ID, nil)
+				nested := createPost(t, postService, authorID, &reply.ID, nil)
+				createPost(t, postService, authorID, &nested.ID, nil)
+				createPost(t, postService, authorID, &reply.ID, nil)
+				return reply.ID, root.ID, root.ID
+			},
+		},
+		{
+			name: "reply under a tombstone",
+			setup: func(t *testing.T, postService *service.PostService) (uuid.UUID, uuid.UUID, uuid.UUID) {
+				root := createPost(t, postService, authorID, nil, nil)
+				createPost(t, postService, authorID, nil, &root.ID)
+				parent := createPost(t, postService, authorID, &root.ID, nil)
+				createPost(t, postService, authorID, &parent.ID, nil)
+				reply := createPost(t, postService, authorID, &parent.ID, nil)
+				if err := postService.DeletePost(ctx, actor, parent.ID, model.DeleteOptions{Soft: true}); err != nil {
+					t.Fatalf("DeletePost(parent): %v", err)
+				}
+				return reply.ID, parent.ID, root.ID
+			},
+		},
+		{
+			name: "restore then delete",
+			setup: func(t *testing.T, postService *service.PostService) (uuid.UUID, uuid.UUID, uuid.UUID) {
+				root := createPost(t, postService, authorID, nil, nil)
+				createPost(t, postService, authorID, &root.ID, nil)
+				createPost(t, postService, authorID, nil, &root.ID)
+				reply := createPost(t, postService, authorID, &root.ID, nil)
+				if err := postService.DeletePost(ctx, actor, reply.ID, model.DeleteOptions{Soft: true}); err != nil {
+					t.Fatalf("DeletePost(reply): %v", err)
+				}
+				if _, err := postService.RestorePost(ctx, actor, reply.ID, ""); err != nil {
+					t.Fatalf("RestorePost(reply): %v", err)
+				}
+				return reply.ID, root.ID, root.ID
+			},
+		},
+	}
+
+	for _, soft := range []bool{false, true} {
+		mode := "hard"
+		if soft {
+			mode = "soft"
+		}
+
+		for _, tc := range cases {
+			soft, tc := soft, tc
+			t.Run(mode+"/"+tc.name, func(t *testing.T) {
+				postService, postRepository := newService(t)
+				target, parentID, originalID := tc.setup(t, postService)
+
+				err := postService.DeletePost(ctx, actor, target, model.DeleteOptions{Soft: soft, Cascade: tc.cascade})
+				if err != nil {
+					t.Fatalf("DeletePost: %v", err)
+				}
+
+				if got := findPost(t, postRepository, parentID).RepliesCount; got != 1 {
+					t.Errorf("replies_count of the parent: got %d, want 1", got)
+				}
+				if got := findPost(t, postRepository, originalID).SharesCount; got != 1 {
+					t.Errorf("shares_count of the original: got %d, want 1", got)
+				}
+			})
+		}
+	}
+}
+
+func TestRestoreModeratedPost(t *testing.T) {
+	ctx := context.Background()
+	author := model.Actor{UserID: uuid.New()}
+	moderator := model.Actor{UserID: uuid.New(), Roles: []string{model.RoleModerator}}
+
+	postService, postRepository := newService(t)
+	post := createPost(t, postService, author.UserID, nil, nil)
+
+	err := postService.DeletePost(ctx, moderator, post.ID, model.DeleteOptions{Soft: true, ModeratorReason: "abuse"})
+	if err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+
+	if _, err := postService.RestorePost(ctx, author, post.ID, ""); !errors.Is(err, service.ErrModerated) {
+		t.Fatalf("RestorePost by the author: got %v, want ErrModerated", err)
+	}
+	if tombstone := findPost(t, postRepository, post.ID); !tombstone.IsDeleted() || tombstone.Content != "" {
+		t.Fatalf("RestorePost by the author brought the content back: %+v", tombstone)
+	}
+
+	if _, err := postService.RestorePost(ctx, moderator, post.ID, ""); !errors.Is(err, service.ErrReasonRequired) {
+		t.Fatalf("RestorePost by a moderator without a reason: got %v, want ErrReasonRequired", err)
+	}
+
+	restored, err := postService.RestorePost(ctx, moderator, post.ID, "appeal granted")
+	if err != nil {
+		t.Fatalf("RestorePost by a moderator: %v", err)
+	}
+	if restored.IsDeleted() || restored.Moderated || restored.Content != post.Content {
+		t.Fatalf("RestorePost by a moderator: got %+v", restored)
+	}
+}
+
+// flakyFeed fails the feed updates until failures runs out
+type flakyFeed struct {
+	*repository.MemoryFeedRepository
+	failures int
+}
+
+var errFeedDown = errors.New("feed service unavailable")
+
+func (f *flakyFeed) PublishPost(ctx context.Context, post model.Post) error {
+	if f.failures > 0 {
+		f.failures--
+		return errFeedDown
+	}
+	return f.MemoryFeedRepository.PublishPost(ctx, post)
+}
+
+func (f *flakyFeed) RetractPost(ctx context.Context, postID uuid.UUID, version int) error {
+	if f.failures > 0 {
+		f.failures--
+		return errFeedDown
+	}
+	return f.MemoryFeedRepository.RetractPost(ctx, postID, version)
+}
+
+func TestDeliverFeedUpdates(t *testing.T) {
+	ctx := context.Background()
+	actor := model.Actor{UserID: uuid.New()}
+
+	feed := &flakyFeed{MemoryFeedRepository: repository.NewMemoryFeedRepository(), failures: 2}
+	postService := service.NewPostService(repository.NewMemoryPostRepository(), repository.NewMemoryBlockRepository(), feed, service.Options{
+		RestoreWindow: time.Hour,
+		EditWindow:    time.Hour,
+	})
+
+	// deliver runs the dispatcher once, expecting it to deliver the given number of updates
+	deliver := func(want int, wantErr error) {
+		t.Helper()
+		delivered, err := postService.DeliverFeedUpdates(ctx)
+		if delivered != want || !errors.Is(err, wantErr) {
+			t.Fatalf("DeliverFeedUpdates: got %d, %v, want %d, %v", delivered, err, want, wantErr)
+		}
+	}
+	// expectFeed checks the version and state the feed holds for a post
+	expectFeed := func(postID uuid.UUID, version int, deleted bool) {
+		t.Helper()
+		got, ok := feed.Post(postID)
+		if !ok || got.Version != version || got.IsDeleted() != deleted {
+			t.Fatalf("feed post: got %+v, %v, want version %d, deleted %v", got, ok, version, deleted)
+		}
+	}
+
+	select {
+	case <-postService.FeedUpdatesQueued():
+		t.Fatalf("FeedUpdatesQueued signaled before any write")
+	default:
+	}
+
+	post := createPost(t, postService, actor.UserID, nil, nil)
+	content := "edited"
+	if _, err := postService.EditPost(ctx, actor, post.ID, model.EditPost{Content: &content, Version: &post.Version}); err != nil {
+		t.Fatalf("EditPost: %v", err)
+	}
+	createPost(t, postService, actor.UserID, &post.ID, nil)
+
+	select {
+	case <-postService.FeedUpdatesQueued():
+	default:
+		t.Fatalf("FeedUpdatesQueued did not signal the queued updates")
+	}
+	if _, ok := feed.Post(post.ID); ok {
+		t.Fatalf("feed received the post before its update was delivered")
+	}
+
+	// The update is kept while the feed service fails, and carries the edit once delivered.
+	// The reply is not part of the feeds and queued nothing.
+	deliver(0, errFeedDown)
+	deliver(0, errFeedDown)
+	deliver(1, nil)
+	deliver(0, nil)
+	expectFeed(post.ID, 1, false)
+	if got, _ := feed.Post(post.ID); got.Content != content {
+		t.Fatalf("feed post content: got %q, want %q", got.Content, content)
+	}
+
+	if err := postService.DeletePost(ctx, actor, post.ID, model.DeleteOptions{Soft: true, Cascade: true}); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	deliver(1, nil)
+	expectFeed(post.ID, 2, true)
+
+	if _, err := postService.RestorePost(ctx, actor, post.ID, ""); err != nil {
+		t.Fatalf("RestorePost: %v", err)
+	}
+	deliver(1, nil)
+	expectFeed(post.ID, 3, false)
+
+	// A hard-deleted post is retracted at the version its deletion took, once it is gone
+	feed.failures = 1
+	if err := postService.DeletePost(ctx, actor, post.ID, model.DeleteOptions{Cascade: true}); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	deliver(0, errFeedDown)
+	deliver(1, nil)
+	expectFeed(post.ID, 4, true)
+}
+
+func TestFeedRetryBackoff(t *testing.T) {
+	ctx := context.Background()
+	feed := &flakyFeed{MemoryFeedRepository: repository.NewMemoryFeedRepository(), failures: 1}
+	postRepository := repository.NewMemoryPostRepository()
+	postService := service.NewPostService(postRepository, repository.NewMemoryBlockRepository(), feed, service.Options{
+		RestoreWindow:  time.Hour,
+		EditWindow:     time.Hour,
+		FeedRetryDelay: time.Hour,
+	})
+
+	post := createPost(t, postService, uuid.New(), nil, nil)
+	if _, err := postService.DeliverFeedUpdates(ctx); !errors.Is(err, errFeedDown) {
+		t.Fatalf("DeliverFeedUpdates: got %v, want %v", err, errFeedDown)
+	}
+
+	// The failed update waits for its retry instead of being sent again right away
+	if delivered, err := postService.DeliverFeedUpdates(ctx); delivered != 0 || err != nil {
+		t.Fatalf("DeliverFeedUpdates before the retry is due: got %d, %v", delivered, err)
+	}
+	update, ok, err := postRepository.ClaimFeedUpdate(ctx, time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+	if err != nil || !ok || update.PostID != post.ID || update.Attempts != 2 {
+		t.Fatalf("ClaimFeedUpdate once the retry is due: got %+v, %v, %v", update, ok, err)
+	}
+}
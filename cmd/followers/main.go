@@ -6,6 +6,7 @@ import (
 	"hornet/api/followers"
 	"hornet/api/followers/repository"
 	"hornet/api/followers/service"
+	"hornet/common/auth"
 	config "hornet/config/followers"
 	"log"
 	"net/http"
@@ -36,11 +37,29 @@ func main() {
 	defer driver.Close(ctx)
 
 	// Initialize repository and service layers
-	followersRepository := repository.NewFollowersRepository(driver)
+	followersRepository := repository.NewNeo4jFollowersRepository(driver)
 	followersService := service.NewFollowersService(followersRepository)
 
+	// Run a one-off maintenance command instead of serving, e.g. `followers migrate`
+	if len(os.Args) > 1 {
+		runCommand(ctx, os.Args[1], followersRepository, followersService)
+		return
+	}
+
+	// Bring the Neo4j schema up to date before serving
+	if cfg.MigrateOnStart {
+		migrate(ctx, followersRepository)
+	}
+
+	// Requests carry the user they are made by, verified as configured
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %v", err)
+	}
+	log.Println("Authenticating requests in", authenticator.Mode(), "mode")
+
 	// Set up router with service
-	r := followers.Router(followersService)
+	r := followers.Router(followersService, authenticator)
 
 	// Start the Gin server
 	server := startServer(r, cfg.ServerPort)
@@ -52,6 +71,36 @@ func main() {
 	gracefulShutdown(server)
 }
 
+// runCommand runs a one-off maintenance command by name.
+func runCommand(ctx context.Context, name string, followersRepository *repository.Neo4jFollowersRepository, followersService *service.FollowersService) {
+	switch name {
+	case "migrate":
+		migrate(ctx, followersRepository)
+	case "repair-follows":
+		removed, err := followersService.RepairDuplicateFollows(ctx)
+		if err != nil {
+			log.Fatalf("Failed to repair follows: %v", err)
+		}
+		log.Printf("Removed %d duplicate follows", removed)
+	default:
+		log.Fatalf("Unknown command %q, expected migrate or repair-follows", name)
+	}
+}
+
+// migrate applies the pending Neo4j schema migrations, exiting on failure.
+func migrate(ctx context.Context, followersRepository *repository.Neo4jFollowersRepository) {
+	applied, err := followersRepository.Migrate(ctx)
+	for _, migration := range applied {
+		log.Printf("Applied schema migration %d: %s", migration.Version, migration.Description)
+	}
+	if err != nil {
+		log.Fatalf("Failed to migrate the Neo4j schema: %v", err)
+	}
+	if len(applied) == 0 {
+		log.Println("Neo4j schema is up to date")
+	}
+}
+
 // handleShutdown listens for interrupt signals to initiate a graceful shutdown.
 func handleShutdown(cancel context.CancelFunc) {
 	c := make(chan os.Signal, 1)
@@ -6,6 +6,7 @@ import (
 	"hornet/api/posts"
 	"hornet/api/posts/repository"
 	"hornet/api/posts/service"
+	"hornet/common/auth"
 	config "hornet/config/posts"
 	"log"
 	"net/http"
@@ -34,11 +35,53 @@ func main() {
 	defer client.Disconnect(ctx)
 
 	// Initialize repository and service layers
-	postRepository := repository.NewPostRepository(db)
-	postService := service.NewPostService(postRepository)
+	postRepository := repository.NewMongoPostRepository(db)
+	if err := postRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create MongoDB indexes: %v", err)
+	}
+
+	// Multi-document operations use transactions when the deployment supports them
+	transactions, err := postRepository.DetectTransactionSupport(ctx)
+	if err != nil {
+		log.Fatalf("Failed to detect MongoDB transaction support: %v", err)
+	}
+	if !transactions {
+		log.Println("MongoDB does not support transactions, falling back to compensating writes")
+	}
+	// Blocks live in the followers service, replies and shares check them there
+	blockRepository := repository.NewHTTPBlockRepository(cfg.FollowersServiceURL, cfg.Auth.ServiceToken, cfg.BlockCacheTTL)
+
+	// New, edited and deleted posts are pushed to the feed service when one is configured
+	var feedRepository repository.FeedRepository
+	if cfg.FeedServiceURL != "" {
+		feedRepository = repository.NewHTTPFeedRepository(cfg.FeedServiceURL, cfg.Auth.ServiceToken)
+	} else {
+		log.Println("FEED_SERVICE_URL is not set, posts will not be pushed to feeds")
+	}
+
+	postService := service.NewPostService(postRepository, blockRepository, feedRepository, service.Options{
+		RestoreWindow:  cfg.RestoreWindow,
+		EditWindow:     cfg.EditWindow,
+		FeedRetryDelay: cfg.FeedRetryDelay,
+	})
+
+	// Hard-delete tombstones once they can no longer be restored
+	go runPurger(ctx, postService, cfg.PurgeInterval)
+
+	// Deliver the queued feed updates, including those left over by a previous run
+	if feedRepository != nil {
+		go runFeedDispatcher(ctx, postService, feedDispatchInterval)
+	}
+
+	// Requests carry the user they are made by, verified as configured
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %v", err)
+	}
+	log.Println("Authenticating requests in", authenticator.Mode(), "mode")
 
 	// Set up router with service
-	r := posts.Router(postService)
+	r := posts.Router(postService, authenticator)
 
 	// Start the Gin server
 	server := startServer(r, cfg.ServerPort)
@@ -59,6 +102,55 @@ func handleShutdown(cancel context.CancelFunc) {
 	cancel() // Cancel the context to initiate shutdown
 }
 
+// runPurger periodically purges the deleted posts whose restore window has passed, until ctx is done.
+func runPurger(ctx context.Context, postService *service.PostService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := postService.PurgeDeletedPosts(ctx)
+			if err != nil {
+				log.Printf("Failed to purge deleted posts: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d deleted posts", purged)
+			}
+		}
+	}
+}
+
+// feedDispatchInterval is how often the feed updates due for a retry are looked for
+const feedDispatchInterval = 5 * time.Second
+
+// runFeedDispatcher delivers the queued feed updates as they are queued, and the failed ones
+// as they come due, until ctx is done.
+func runFeedDispatcher(ctx context.Context, postService *service.PostService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		delivered, err := postService.DeliverFeedUpdates(ctx)
+		if err != nil {
+			log.Printf("Failed to deliver feed updates: %v", err)
+		}
+		if delivered > 0 {
+			log.Printf("Delivered %d feed updates", delivered)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-postService.FeedUpdatesQueued():
+		}
+	}
+}
+
 // setupMongoClient initializes and returns a MongoDB client and the database.
 func setupMongoClient(ctx context.Context, uri, dbName string) (*mongo.Client, *mongo.Database) {
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
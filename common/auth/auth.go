@@ -0,0 +1,318 @@
+// Package auth authenticates the user behind a request and puts their Principal into the
+// request context. Bearer JWTs are verified locally against a JWKS, or, as an explicit
+// opt-in, the identity headers set by an Istio mesh are trusted as they are.
+// Calls between the services are authenticated apart, by a shared service token.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hornet/common/problem"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Mode selects how requests are authenticated
+type Mode string
+
+const (
+	// ModeJWT verifies the bearer token of the Authorization header against a JWKS
+	ModeJWT Mode = "jwt"
+
+	// ModeMesh trusts the X-User-ID and X-User-Roles headers, which only Istio may set.
+	// Anyone reaching the service without going through the mesh can impersonate any user.
+	ModeMesh Mode = "mesh"
+)
+
+const (
+	// DefaultRolesClaim is the claim roles are read from, as emitted by the Keycloak mapper
+	DefaultRolesClaim = "roles"
+
+	// DefaultRefreshInterval is how often the keys of a JWKS URL are fetched again
+	DefaultRefreshInterval = 15 * time.Minute
+
+	// ServiceTokenHeader carries the service token on calls between the services
+	ServiceTokenHeader = "X-Service-Token"
+
+	// minServiceTokenLength is the shortest service token accepted, in bytes
+	minServiceTokenLength = 32
+)
+
+var (
+	// ErrUnauthenticated is returned when a request needs a user but carries no credentials
+	ErrUnauthenticated = problem.Unauthenticated("unauthenticated", "Authentication is required")
+
+	// ErrInvalidCredentials is returned when the credentials of a request cannot be verified
+	ErrInvalidCredentials = problem.Unauthenticated("invalid_credentials", "The credentials of the request are invalid")
+
+	// ErrServiceRequired is returned when a route meant for other services is called without the service token
+	ErrServiceRequired = problem.Unauthenticated("service_required", "Only other services may call this route")
+)
+
+// Principal is the authenticated user a request is made by
+type Principal struct {
+	UserID uuid.UUID
+	Roles  []string
+	Token  string // The bearer token the user was authenticated with, empty in mesh mode
+}
+
+// HasRole reports whether the principal holds the role
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// principalKey keys the Principal in a request context
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying the principal
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal carried by ctx, if the request was authenticated
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// PrincipalFrom returns the principal of a request that went through the Middleware, if any
+func PrincipalFrom(c *gin.Context) (Principal, bool) {
+	return FromContext(c.Request.Context())
+}
+
+// serviceKey marks a request context as made by another service
+type serviceKey struct{}
+
+// FromService reports whether the request went through the Middleware with a valid service token
+func FromService(c *gin.Context) bool {
+	service, _ := c.Request.Context().Value(serviceKey{}).(bool)
+	return service
+}
+
+// Config holds the settings of an Authenticator
+type Config struct {
+	Mode            Mode
+	JWKSURL         string        // Where the verification keys are fetched from, in JWT mode
+	JWKSFile        string        // Where the verification keys are read from, in JWT mode, instead of a URL
+	Issuer          string        // The iss claim tokens must carry, unchecked when empty
+	Audience        string        // A value the aud claim of tokens must hold, unchecked when empty
+	RolesClaim      string        // The claim roles are read from, a dotted path for nested claims
+	RefreshInterval time.Duration // How often the keys of JWKSURL are fetched again
+	ServiceToken    string        // The secret other services authenticate with, service routes are closed when empty
+}
+
+// ConfigFromEnv reads the AUTH_* environment variables. JWT verification is the default,
+// trusting the mesh headers requires AUTH_MODE=mesh. New checks the result is complete.
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Mode:            Mode(os.Getenv("AUTH_MODE")),
+		JWKSURL:         os.Getenv("AUTH_JWKS_URL"),
+		JWKSFile:        os.Getenv("AUTH_JWKS_FILE"),
+		Issuer:          os.Getenv("AUTH_ISSUER"),
+		Audience:        os.Getenv("AUTH_AUDIENCE"),
+		RolesClaim:      os.Getenv("AUTH_ROLES_CLAIM"),
+		RefreshInterval: DefaultRefreshInterval,
+		ServiceToken:    os.Getenv("AUTH_SERVICE_TOKEN"),
+	}
+	if config.Mode == "" {
+		config.Mode = ModeJWT
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = DefaultRolesClaim
+	}
+
+	if value := os.Getenv("AUTH_JWKS_REFRESH_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return Config{}, fmt.Errorf("AUTH_JWKS_REFRESH_INTERVAL must be a positive duration, got %q", value)
+		}
+		config.RefreshInterval = interval
+	}
+
+	return config, nil
+}
+
+// validate checks that the configuration selects a mode and, in JWT mode, a single key source
+func (c Config) validate() error {
+	if c.ServiceToken != "" && len(c.ServiceToken) < minServiceTokenLength {
+		return fmt.Errorf("AUTH_SERVICE_TOKEN must be at least %d bytes long", minServiceTokenLength)
+	}
+
+	switch c.Mode {
+	case ModeMesh:
+		return nil
+	case ModeJWT:
+		if (c.JWKSURL == "") == (c.JWKSFile == "") {
+			return errors.New("exactly one of AUTH_JWKS_URL and AUTH_JWKS_FILE must be set in jwt mode")
+		}
+		return nil
+	default:
+		return fmt.Errorf("AUTH_MODE must be %q or %q, got %q", ModeJWT, ModeMesh, c.Mode)
+	}
+}
+
+// Authenticator authenticates requests according to its Config
+type Authenticator struct {
+	config Config
+	keys   *keySet // Nil in mesh mode
+	now    func() time.Time
+}
+
+// New creates an Authenticator. In JWT mode the keys are loaded right away,
+// so a misconfigured JWKS fails at startup rather than on the first request.
+func New(config Config) (*Authenticator, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = DefaultRolesClaim
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DefaultRefreshInterval
+	}
+
+	a := &Authenticator{config: config, now: time.Now}
+	if config.Mode == ModeMesh {
+		return a, nil
+	}
+
+	// The keys share the clock of the Authenticator, read through it so tests may replace it
+	now := func() time.Time { return a.now() }
+
+	var err error
+	if config.JWKSFile != "" {
+		a.keys, err = newFileKeySet(config.JWKSFile, now)
+	} else {
+		a.keys, err = newURLKeySet(config.JWKSURL, config.RefreshInterval, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Mode returns how the Authenticator authenticates requests
+func (a *Authenticator) Mode() Mode {
+	return a.config.Mode
+}
+
+// Authenticate returns the principal of a request. The boolean is false when the request
+// carries no credentials, and an error is returned when they cannot be verified.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, bool, error) {
+	if a.config.Mode == ModeMesh {
+		return authenticateMesh(r)
+	}
+
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return Principal{}, false, nil
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, false, errors.New("authorization must be a bearer token")
+	}
+
+	principal, err := a.verifyToken(strings.TrimSpace(token), a.now())
+	if err != nil {
+		return Principal{}, false, err
+	}
+	return principal, true, nil
+}
+
+// authenticateMesh reads the principal from the identity headers set by Istio
+func authenticateMesh(r *http.Request) (Principal, bool, error) {
+	userIDStr := r.Header.Get("X-User-ID")
+	if userIDStr == "" {
+		return Principal{}, false, nil
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return Principal{}, false, fmt.Errorf("invalid X-User-ID %q", userIDStr)
+	}
+
+	return Principal{UserID: userID, Roles: parseRoles(r.Header.Get("X-User-Roles"))}, true, nil
+}
+
+// authenticateService reports whether the request carries the service token.
+// A wrong token is an error, as is any token when none is configured.
+func (a *Authenticator) authenticateService(r *http.Request) (bool, error) {
+	token := r.Header.Get(ServiceTokenHeader)
+	if token == "" {
+		return false, nil
+	}
+
+	if a.config.ServiceToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.config.ServiceToken)) != 1 {
+		return false, errors.New("invalid service token")
+	}
+	return true, nil
+}
+
+// Middleware authenticates every request and puts its principal into the request context,
+// and marks the calls of other services. Requests without credentials go through
+// unauthenticated, handlers that need a user answer them with ErrUnauthenticated.
+// Requests with invalid credentials are rejected.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, err := a.authenticateService(c.Request)
+		if err != nil {
+			problem.Respond(c, fmt.Errorf("%w: %v", ErrInvalidCredentials, err))
+			return
+		}
+
+		principal, ok, err := a.Authenticate(c.Request)
+		if err != nil {
+			if a.config.Mode == ModeJWT {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			problem.Respond(c, fmt.Errorf("%w: %v", ErrInvalidCredentials, err))
+			return
+		}
+
+		ctx := c.Request.Context()
+		if service {
+			ctx = context.WithValue(ctx, serviceKey{}, true)
+		}
+		if ok {
+			ctx = NewContext(ctx, principal)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireService rejects the requests that do not carry the service token, for routes only
+// the other services may call. It runs after the Middleware.
+func RequireService() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !FromService(c) {
+			problem.Respond(c, ErrServiceRequired)
+			return
+		}
+		c.Next()
+	}
+}
+
+// parseRoles splits a comma-separated list of roles
+func parseRoles(list string) []string {
+	var roles []string
+	for _, role := range strings.Split(list, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
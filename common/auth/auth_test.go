@@ -0,0 +1,366 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"hornet/common/auth/authtest"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newIssuer(t *testing.T) *authtest.Issuer {
+	t.Helper()
+	issuer, err := authtest.NewIssuer()
+	if err != nil {
+		t.Fatalf("NewIssuer: %v", err)
+	}
+	return issuer
+}
+
+// newFileAuthenticator creates an Authenticator in JWT mode reading the keys of the issuer from a file
+func newFileAuthenticator(t *testing.T, issuer *authtest.Issuer, config Config) *Authenticator {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, issuer.JWKS(), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	config.Mode = ModeJWT
+	config.JWKSFile = path
+	authenticator, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return authenticator
+}
+
+// bearerRequest returns a request carrying the token in its Authorization header
+func bearerRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// sign returns a token signed by the issuer with the claims of a valid token for userID, overridden by extra
+func sign(t *testing.T, issuer *authtest.Issuer, userID uuid.UUID, extra map[string]interface{}) string {
+	t.Helper()
+	now := time.Now()
+	claims := map[string]interface{}{
+		"sub": userID.String(),
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+
+	token, err := issuer.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+// unsignedToken returns a token with the given header and claims, and the given signature
+func unsignedToken(t *testing.T, header, claims map[string]interface{}, sign func(signingInput string) []byte) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signingInput := encode(header) + "." + encode(claims)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign(signingInput))
+}
+
+func TestAuthenticateValidToken(t *testing.T) {
+	issuer := newIssuer(t)
+	authenticator := newFileAuthenticator(t, issuer, Config{})
+
+	userID := uuid.New()
+	token, err := issuer.Token(userID, []string{"moderator"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+
+	principal, ok, err := authenticator.Authenticate(bearerRequest(token))
+	if err != nil || !ok {
+		t.Fatalf("Authenticate: got %v, %v", ok, err)
+	}
+	if principal.UserID != userID || !principal.HasRole("moderator") || principal.Token != token {
+		t.Fatalf("Authenticate: got %+v", principal)
+	}
+}
+
+func TestAuthenticateClaims(t *testing.T) {
+	issuer := newIssuer(t)
+	authenticator := newFileAuthenticator(t, issuer, Config{Issuer: "https://id.hornet", Audience: "hornet"})
+
+	now := time.Now()
+	valid := map[string]interface{}{"iss": "https://id.hornet", "aud": "hornet"}
+	with := func(extra map[string]interface{}) map[string]interface{} {
+		claims := map[string]interface{}{}
+		for name, value := range valid {
+			claims[name] = value
+		}
+		for name, value := range extra {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	cases := []struct {
+		name   string
+		claims map[string]interface{}
+		valid  bool
+	}{
+		{"valid", valid, true},
+		{"audience among several", with(map[string]interface{}{"aud": []string{"other", "hornet"}}), true},
+		{"expired within the clock skew", with(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()}), true},
+		{"expired", with(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()}), false},
+		{"not valid yet", with(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()}), false},
+		{"valid since nbf", with(map[string]interface{}{"nbf": now.Add(-time.Hour).Unix()}), true},
+		{"wrong issuer", with(map[string]interface{}{"iss": "https://evil.example"}), false},
+		{"missing issuer", with(map[string]interface{}{"iss": nil}), false},
+		{"wrong audience", with(map[string]interface{}{"aud": "other"}), false},
+		{"missing audience", with(map[string]interface{}{"aud": nil}), false},
+		{"subject not a user ID", with(map[string]interface{}{"sub": "admin"}), false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			token := sign(t, issuer, uuid.New(), tc.claims)
+
+			_, ok, err := authenticator.Authenticate(bearerRequest(token))
+			if tc.valid && (err != nil || !ok) {
+				t.Fatalf("Authenticate: got %v, %v, want a principal", ok, err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatalf("Authenticate: got %v, want an error", ok)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRejectsOtherAlgorithms(t *testing.T) {
+	issuer := newIssuer(t)
+	authenticator := newFileAuthenticator(t, issuer, Config{})
+
+	claims := map[string]interface{}{
+		"sub": uuid.NewString(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{
+			name: "none",
+			token: unsignedToken(t, map[string]interface{}{"alg": "none", "kid": issuer.KeyID}, claims, func(string) []byte {
+				return nil
+			}),
+		},
+		{
+			// The public key is known to anyone, an HMAC keyed with it must not pass for RS256
+			name: "HS256 keyed with the public key",
+			token: unsignedToken(t, map[string]interface{}{"alg": "HS256", "kid": issuer.KeyID}, claims, func(signingInput string) []byte {
+				mac := hmac.New(sha256.New, issuer.JWKS())
+				mac.Write([]byte(signingInput))
+				return mac.Sum(nil)
+			}),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, ok, err := authenticator.Authenticate(bearerRequest(tc.token)); err == nil {
+				t.Fatalf("Authenticate: got %v, want an error", ok)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRefetchesUnknownKeyID(t *testing.T) {
+	issuer := newIssuer(t)
+	server := issuer.Server()
+	defer server.Close()
+
+	authenticator, err := New(Config{Mode: ModeJWT, JWKSURL: server.URL, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Now()
+	authenticator.now = func() time.Time { return now }
+
+	authenticate := func(token string) error {
+		_, _, err := authenticator.Authenticate(bearerRequest(token))
+		return err
+	}
+
+	if err := authenticate(sign(t, issuer, uuid.New(), nil)); err != nil {
+		t.Fatalf("Authenticate with the first key: %v", err)
+	}
+
+	if err := issuer.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	rotated := sign(t, issuer, uuid.New(), nil)
+
+	// The keys were fetched at startup, too recently to fetch them again
+	if err := authenticate(rotated); err == nil {
+		t.Fatal("Authenticate with the rotated key right after startup: got no error")
+	}
+	if got := issuer.Fetches(); got != 1 {
+		t.Fatalf("fetches right after startup: got %d, want 1", got)
+	}
+
+	now = now.Add(2 * minRefetchInterval)
+	if err := authenticate(rotated); err != nil {
+		t.Fatalf("Authenticate with the rotated key: %v", err)
+	}
+	if got := issuer.Fetches(); got != 2 {
+		t.Fatalf("fetches after the rotation: got %d, want 2", got)
+	}
+
+	// Key IDs nobody publishes do not turn every request into a fetch
+	for i := 0; i < 3; i++ {
+		token := unsignedToken(t, map[string]interface{}{"alg": "RS256", "kid": uuid.NewString()}, map[string]interface{}{"sub": uuid.NewString()}, func(string) []byte {
+			return []byte("signature")
+		})
+		if err := authenticate(token); err == nil {
+			t.Fatal("Authenticate with an unknown key ID: got no error")
+		}
+	}
+	if got := issuer.Fetches(); got != 2 {
+		t.Fatalf("fetches after unknown key IDs: got %d, want 2", got)
+	}
+}
+
+func TestAuthenticateNestedRolesClaim(t *testing.T) {
+	issuer := newIssuer(t)
+	authenticator := newFileAuthenticator(t, issuer, Config{RolesClaim: "realm_access.roles"})
+
+	token := sign(t, issuer, uuid.New(), map[string]interface{}{
+		"realm_access": map[string]interface{}{"roles": []string{"moderator", "user"}},
+		"roles":        []string{"admin"},
+	})
+
+	principal, _, err := authenticator.Authenticate(bearerRequest(token))
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if !principal.HasRole("moderator") || !principal.HasRole("user") || principal.HasRole("admin") {
+		t.Fatalf("roles: got %v, want [moderator user]", principal.Roles)
+	}
+
+	token = sign(t, issuer, uuid.New(), map[string]interface{}{"realm_access": map[string]interface{}{}})
+	principal, _, err = authenticator.Authenticate(bearerRequest(token))
+	if err != nil || len(principal.Roles) != 0 {
+		t.Fatalf("Authenticate without roles: got %v, %v, want no roles", principal.Roles, err)
+	}
+}
+
+func TestAuthenticateMesh(t *testing.T) {
+	authenticator, err := New(Config{Mode: ModeMesh})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	userID := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User-ID", userID.String())
+	req.Header.Set("X-User-Roles", "moderator, user")
+
+	principal, ok, err := authenticator.Authenticate(req)
+	if err != nil || !ok {
+		t.Fatalf("Authenticate: got %v, %v", ok, err)
+	}
+	if principal.UserID != userID || !principal.HasRole("moderator") || !principal.HasRole("user") {
+		t.Fatalf("Authenticate: got %+v", principal)
+	}
+
+	req.Header.Set("X-User-ID", "admin")
+	if _, _, err := authenticator.Authenticate(req); err == nil {
+		t.Fatal("Authenticate with an invalid X-User-ID: got no error")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	issuer := newIssuer(t)
+	serviceToken := "a-service-token-of-at-least-32-bytes"
+
+	jwt := newFileAuthenticator(t, issuer, Config{ServiceToken: serviceToken})
+	mesh, err := New(Config{Mode: ModeMesh})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	token, err := issuer.Token(uuid.New(), nil, time.Hour)
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+
+	cases := []struct {
+		name          string
+		authenticator *Authenticator
+		headers       map[string]string
+		status        int
+	}{
+		{"jwt without credentials", jwt, nil, http.StatusUnauthorized},
+		{"mesh without credentials", mesh, nil, http.StatusUnauthorized},
+		{"jwt with a token", jwt, map[string]string{"Authorization": "Bearer " + token}, http.StatusOK},
+		{"mesh with a user", mesh, map[string]string{"X-User-ID": uuid.NewString()}, http.StatusOK},
+		{"jwt with an invalid token", jwt, map[string]string{"Authorization": "Bearer " + token + "x"}, http.StatusUnauthorized},
+		{"jwt with a basic authorization", jwt, map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, http.StatusUnauthorized},
+		{"service token", jwt, map[string]string{ServiceTokenHeader: serviceToken}, http.StatusNoContent},
+		{"wrong service token", jwt, map[string]string{ServiceTokenHeader: serviceToken + "x"}, http.StatusUnauthorized},
+		{"service token where none is configured", mesh, map[string]string{ServiceTokenHeader: serviceToken}, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(tc.authenticator.Middleware())
+			router.GET("/", func(c *gin.Context) {
+				if FromService(c) {
+					c.Status(http.StatusNoContent)
+					return
+				}
+				if _, ok := PrincipalFrom(c); !ok {
+					c.Status(http.StatusUnauthorized)
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status: got %d, want %d", rec.Code, tc.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package authtest issues tokens signed with locally generated keys, for exercising
+// auth.Authenticator in JWT mode without an identity provider:
+//
+//	issuer, err := authtest.NewIssuer()
+//	...
+//	path := filepath.Join(t.TempDir(), "jwks.json")
+//	os.WriteFile(path, issuer.JWKS(), 0o600)
+//	authenticator, err := auth.New(auth.Config{Mode: auth.ModeJWT, JWKSFile: path})
+//	...
+//	token, err := issuer.Token(userID, []string{"moderator"}, time.Hour)
+//	req.Header.Set("Authorization", "Bearer "+token)
+package authtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// keyBits is the size of the generated keys, the shortest auth accepts
+const keyBits = 2048
+
+// Issuer signs tokens with an RSA key it generated
+type Issuer struct {
+	Key   *rsa.PrivateKey // Replaced by Rotate
+	KeyID string          // Replaced by Rotate
+	Name  string          // The iss claim of the tokens, empty to leave it out
+
+	mu      sync.Mutex // Guards the key while Server answers in the background
+	fetches int        // Number of requests Server answered
+}
+
+// NewIssuer generates a key and returns an Issuer signing with it
+func NewIssuer() (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return nil, err
+	}
+	return &Issuer{Key: key, KeyID: uuid.NewString()}, nil
+}
+
+// Rotate replaces the key of the issuer with a newly generated one, under a new key ID
+func (i *Issuer) Rotate() error {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.Key, i.KeyID = key, uuid.NewString()
+	return nil
+}
+
+// JWKS returns the JWKS document publishing the public key of the issuer
+func (i *Issuer) JWKS() []byte {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	document := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": i.KeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(i.Key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.Key.E)).Bytes()),
+		}},
+	}
+	data, _ := json.Marshal(document)
+	return data
+}
+
+// Server starts an HTTP server answering every request with the JWKS of the issuer,
+// and counting them in Fetches. The caller closes it.
+func (i *Issuer) Server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i.mu.Lock()
+		i.fetches++
+		i.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(i.JWKS())
+	}))
+}
+
+// Fetches returns the number of requests Server answered
+func (i *Issuer) Fetches() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.fetches
+}
+
+// Token returns a token for the user with the given roles, valid for ttl
+func (i *Issuer) Token(userID uuid.UUID, roles []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"sub":   userID.String(),
+		"roles": roles,
+		"iat":   now.Unix(),
+		"exp":   now.Add(ttl).Unix(),
+	}
+	if i.Name != "" {
+		claims["iss"] = i.Name
+	}
+	return i.Sign(claims)
+}
+
+// Sign returns an RS256 token carrying the given claims, as they are
+func (i *Issuer) Sign(claims map[string]interface{}) (string, error) {
+	i.mu.Lock()
+	key, keyID := i.Key, i.KeyID
+	i.mu.Unlock()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minRefetchInterval rate-limits fetching the key set again, so tokens naming random
+// key IDs, or an unavailable issuer, do not turn every request into a JWKS fetch
+const minRefetchInterval = time.Minute
+
+// minKeyBits is the shortest RSA key accepted for verifying tokens
+const minKeyBits = 2048
+
+// jwk is a JSON Web Key, as listed in a JWKS document
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// ParseJWKS reads the RSA signing keys of a JWKS document, by key ID.
+// Keys of other types or meant for encryption are skipped.
+func ParseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(document.Keys))
+	for _, key := range document.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of key %q: %w", key.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of key %q: %w", key.Kid, err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent of key %q", key.Kid)
+		}
+
+		modulus := new(big.Int).SetBytes(n)
+		if modulus.BitLen() < minKeyBits {
+			return nil, fmt.Errorf("key %q is shorter than %d bits", key.Kid, minKeyBits)
+		}
+
+		keys[key.Kid] = &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no RSA signing key")
+	}
+	return keys, nil
+}
+
+// keySet holds the verification keys read from a JWKS file or URL.
+// Keys from a URL are fetched again once refreshInterval has passed, or when a token
+// names a key ID the set does not know, as happens after the issuer rotates its keys.
+type keySet struct {
+	load            func() ([]byte, error)
+	refreshInterval time.Duration // Zero for a file, which is read once
+	now             func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time     // When the keys were last loaded
+	attemptedAt time.Time     // When loading them was last attempted, successfully or not
+	refreshing  chan struct{} // Closed when the fetch in flight ends, nil when none is
+}
+
+// newFileKeySet reads the keys of a JWKS file
+func newFileKeySet(path string, now func() time.Time) (*keySet, error) {
+	set := &keySet{
+		load: func() ([]byte, error) {
+			return os.ReadFile(path)
+		},
+		now: now,
+	}
+	if err := set.init(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// newURLKeySet fetches the keys of a JWKS endpoint
+func newURLKeySet(url string, refreshInterval time.Duration, now func() time.Time) (*keySet, error) {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	set := &keySet{
+		load: func() ([]byte, error) {
+			resp, err := httpClient.Get(url)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+			}
+			return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		},
+		refreshInterval: refreshInterval,
+		now:             now,
+	}
+	if err := set.init(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// init loads the first keys of the set
+func (s *keySet) init() error {
+	keys, err := s.fetch()
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	s.fetchedAt = s.now()
+	s.attemptedAt = s.fetchedAt
+	return nil
+}
+
+// fetch loads and parses the keys, without touching the set
+func (s *keySet) fetch() (map[string]*rsa.PublicKey, error) {
+	data, err := s.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWKS: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// refresh fetches the keys again when they are stale or lack the key ID, at most once per
+// minRefetchInterval. The fetch runs outside the lock, one at a time, so tokens signed with
+// known keys are verified meanwhile; tokens naming the missing key ID wait for it.
+// A failed fetch keeps the previous keys, the issuer may be briefly unavailable.
+func (s *keySet) refresh(kid string) {
+	s.mu.Lock()
+	_, known := s.keys[kid]
+	missing := !known && kid != ""
+
+	if s.refreshing != nil {
+		done := s.refreshing
+		s.mu.Unlock()
+		if missing {
+			<-done
+		}
+		return
+	}
+
+	now := s.now()
+	stale := now.Sub(s.fetchedAt) > s.refreshInterval || missing
+	if !stale || now.Sub(s.attemptedAt) <= minRefetchInterval {
+		s.mu.Unlock()
+		return
+	}
+
+	done := make(chan struct{})
+	s.refreshing = done
+	s.attemptedAt = now
+	s.mu.Unlock()
+
+	keys, err := s.fetch()
+
+	s.mu.Lock()
+	if err == nil {
+		s.keys = keys
+		s.fetchedAt = now
+	}
+	s.refreshing = nil
+	s.mu.Unlock()
+	close(done)
+}
+
+// key returns the key with the given ID. An empty ID is accepted when the set holds a single key.
+func (s *keySet) key(kid string) (*rsa.PublicKey, error) {
+	if s.refreshInterval > 0 {
+		s.refresh(kid)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key ID %q", kid)
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers SHA-256 for RS256
+	_ "crypto/sha512" // Registers SHA-384 and SHA-512 for RS384 and RS512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// clockSkew is how far the clocks of the issuer and the services may drift apart
+const clockSkew = 30 * time.Second
+
+// algorithms maps the signing algorithms accepted in token headers to their hash
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+}
+
+// tokenHeader is the JOSE header of a token
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// tokenClaims are the registered claims checked on every token
+type tokenClaims struct {
+	Subject   string       `json:"sub"`
+	Issuer    string       `json:"iss"`
+	Audience  audience     `json:"aud"`
+	ExpiresAt *numericDate `json:"exp"`
+	NotBefore *numericDate `json:"nbf"`
+}
+
+// audience is the aud claim, either a single string or a list of them
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("aud must be a string or a list of strings")
+	}
+	*a = list
+	return nil
+}
+
+// maxNumericDate bounds the dates of a token, in seconds, well within the range of time.Time
+const maxNumericDate = 1e11
+
+// numericDate is a JWT time, in seconds since the epoch, possibly fractional
+type numericDate struct {
+	time.Time
+}
+
+func (d *numericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil || math.Abs(seconds) > maxNumericDate {
+		return errors.New("dates must be numbers of seconds")
+	}
+	whole, fraction := math.Modf(seconds)
+	d.Time = time.Unix(int64(whole), int64(fraction*float64(time.Second)))
+	return nil
+}
+
+// verifyToken checks the signature and the registered claims of a compact JWT,
+// and returns the principal it authenticates
+func (a *Authenticator) verifyToken(token string, now time.Time) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, errors.New("token must have three parts")
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("invalid header: %w", err)
+	}
+
+	// The algorithm is checked against a fixed list, so none or HMAC tokens are rejected
+	hash, ok := algorithms[header.Alg]
+	if !ok {
+		return Principal{}, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	key, err := a.keys.key(header.Kid)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	digest := hash.New()
+	digest.Write([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, hash, digest.Sum(nil), signature); err != nil {
+		return Principal{}, errors.New("invalid signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Principal{}, fmt.Errorf("invalid payload encoding: %w", err)
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Principal{}, fmt.Errorf("invalid claims: %w", err)
+	}
+
+	if err := a.checkClaims(claims, now); err != nil {
+		return Principal{}, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return Principal{}, errors.New("sub must be a user ID")
+	}
+
+	roles, err := readRoles(payload, a.config.RolesClaim)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	return Principal{UserID: userID, Roles: roles, Token: token}, nil
+}
+
+// checkClaims checks the validity period of a token, and its issuer and audience when configured
+func (a *Authenticator) checkClaims(claims tokenClaims, now time.Time) error {
+	if claims.ExpiresAt == nil {
+		return errors.New("exp is required")
+	}
+	if now.After(claims.ExpiresAt.Add(clockSkew)) {
+		return errors.New("token has expired")
+	}
+	if claims.NotBefore != nil && now.Add(clockSkew).Before(claims.NotBefore.Time) {
+		return errors.New("token is not valid yet")
+	}
+
+	if a.config.Issuer != "" && claims.Issuer != a.config.Issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+
+	if a.config.Audience != "" {
+		for _, aud := range claims.Audience {
+			if aud == a.config.Audience {
+				return nil
+			}
+		}
+		return errors.New("token is not meant for this audience")
+	}
+	return nil
+}
+
+// readRoles reads the roles of a token from a claim holding either a list of roles
+// or a comma-separated string, as emitted for the mesh. A dotted claim name such as
+// realm_access.roles reads a nested claim. A missing or null claim grants no role.
+func readRoles(payload []byte, claim string) ([]string, error) {
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return nil, err
+	}
+
+	for _, name := range strings.Split(claim, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		if value, ok = object[name]; !ok {
+			return nil, nil
+		}
+	}
+
+	switch roles := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return parseRoles(roles), nil
+	case []interface{}:
+		list := make([]string, 0, len(roles))
+		for _, role := range roles {
+			name, ok := role.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must only hold strings", claim)
+			}
+			list = append(list, name)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("%s must be a string or a list of strings", claim)
+	}
+}
+
+// decodeSegment decodes a base64url JSON segment of a token into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,233 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTimeout bounds a request when the context sets no deadline of its own
+const DefaultTimeout = 10 * time.Second
+
+var (
+	// ErrBadRequest matches a StatusError for a 400 response
+	ErrBadRequest = errors.New("bad request")
+
+	// ErrUnauthenticated matches a StatusError for a 401 response
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden matches a StatusError for a 403 response
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrNotFound matches a StatusError for a 404 response
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict matches a StatusError for a 409 response
+	ErrConflict = errors.New("conflict")
+
+	// ErrUnprocessable matches a StatusError for a 422 response
+	ErrUnprocessable = errors.New("unprocessable entity")
+)
+
+// statusErrors maps the statuses callers commonly branch on to their sentinel errors
+var statusErrors = map[int]error{
+	http.StatusBadRequest:          ErrBadRequest,
+	http.StatusUnauthorized:        ErrUnauthenticated,
+	http.StatusForbidden:           ErrForbidden,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusConflict:            ErrConflict,
+	http.StatusUnprocessableEntity: ErrUnprocessable,
+}
+
+// StatusError is returned when a service answers with an error status.
+// It matches the sentinel error of its status with errors.Is, e.g. ErrNotFound for a 404.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Code       string // The machine-readable code of the problem details, e.g. post_not_found
+	Message    string // The detail of the problem details, or their title when there is none
+	Field      string // The invalid request field, set on validation errors
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s: status %d", e.Method, e.Path, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// Is reports whether target is the sentinel error of the response status
+func (e *StatusError) Is(target error) bool {
+	return statusErrors[e.StatusCode] == target
+}
+
+// contextKey keys the identity a request is sent on behalf of
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	rolesKey
+	tokenKey
+	serviceTokenKey
+)
+
+// WithUserID returns a copy of ctx whose requests are sent on behalf of the user, as X-User-ID
+func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFrom returns the user requests sent with ctx are on behalf of, if any
+func UserIDFrom(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(userIDKey).(uuid.UUID)
+	return userID, ok && userID != uuid.Nil
+}
+
+// WithRoles returns a copy of ctx whose requests carry the user's roles, as X-User-Roles
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesKey, roles)
+}
+
+// WithBearerToken returns a copy of ctx whose requests carry the token, as an Authorization header.
+// Services verifying tokens ignore X-User-ID, requests on behalf of a user forward their token.
+func WithBearerToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey, token)
+}
+
+// WithServiceToken returns a copy of ctx whose requests carry the service token, as X-Service-Token,
+// for the routes only the other services may call
+func WithServiceToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, serviceTokenKey, token)
+}
+
+// Page selects a page of a cursor-paginated list. Zero values are left to the service defaults.
+type Page struct {
+	Limit  int
+	After  string // The next_cursor of the previous page
+	Before string // The prev_cursor of the following page
+}
+
+// Values returns the query parameters selecting the page
+func (p Page) Values() url.Values {
+	values := url.Values{}
+	if p.Limit > 0 {
+		values.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.After != "" {
+		values.Set("after", p.After)
+	}
+	if p.Before != "" {
+		values.Set("before", p.Before)
+	}
+	return values
+}
+
+// Client sends JSON requests to one service
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a Client for the service at baseURL. A nil httpClient uses DefaultTimeout.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Do sends a request with body encoded as JSON, unless nil, and decodes a successful
+// response into out, unless nil. It returns the response status, and a *StatusError
+// when the status is not 2xx.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (int, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	if userID, ok := UserIDFrom(ctx); ok {
+		req.Header.Set("X-User-ID", userID.String())
+	}
+	if roles, ok := ctx.Value(rolesKey).([]string); ok && len(roles) > 0 {
+		req.Header.Set("X-User-Roles", strings.Join(roles, ","))
+	}
+	if token, ok := ctx.Value(tokenKey).(string); ok && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if token, ok := ctx.Value(serviceTokenKey).(string); ok && token != "" {
+		req.Header.Set("X-Service-Token", token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, newStatusError(method, path, resp)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("%s %s: failed to decode response: %w", method, path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// newStatusError reads the RFC 7807 problem details the services answer errors with
+func newStatusError(method, path string, resp *http.Response) *StatusError {
+	statusErr := &StatusError{
+		Method:     method,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+	}
+
+	var details struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+		Code   string `json:"code"`
+		Field  string `json:"field"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&details); err == nil {
+		statusErr.Code = details.Code
+		statusErr.Message = details.Detail
+		if statusErr.Message == "" {
+			statusErr.Message = details.Title
+		}
+		statusErr.Field = details.Field
+	}
+	return statusErr
+}
@@ -0,0 +1,162 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"hornet/common/problem"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultLimit is the page size used when the client does not provide one
+	DefaultLimit = 20
+
+	// MaxLimit is the largest page size a client may request
+	MaxLimit = 100
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded
+var ErrInvalidCursor = problem.BadRequest("invalid_cursor", "Invalid cursor")
+
+// Cursor identifies a position in a list ordered by creation time,
+// optionally preceded by a ranking score for lists sorted by popularity.
+// The ID breaks ties between items created at the same instant.
+type Cursor struct {
+	Score     int
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// cursorPayload is the wire representation of a Cursor
+type cursorPayload struct {
+	Score     int    `json:"s,omitempty"`
+	CreatedAt int64  `json:"t,omitempty"`
+	ID        string `json:"id"`
+}
+
+// Encode returns the opaque string representation of the cursor
+func (c Cursor) Encode() string {
+	payload := cursorPayload{
+		Score: c.Score,
+		ID:    c.ID.String(),
+	}
+	// Lists ranked by score alone leave the creation time out
+	if !c.CreatedAt.IsZero() {
+		payload.CreatedAt = c.CreatedAt.UnixNano()
+	}
+
+	raw, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor previously produced by Encode
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	id, err := uuid.Parse(payload.ID)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	cursor := Cursor{
+		Score: payload.Score,
+		ID:    id,
+	}
+	if payload.CreatedAt != 0 {
+		cursor.CreatedAt = time.Unix(0, payload.CreatedAt).UTC()
+	}
+	return cursor, nil
+}
+
+// Request describes the page a client asked for.
+// At most one of After and Before is set.
+type Request struct {
+	Limit  int
+	After  *Cursor // Return the items that follow this cursor in listing order
+	Before *Cursor // Return the items that precede this cursor in listing order
+}
+
+// Backward reports whether the page is read towards the start of the list
+func (r Request) Backward() bool {
+	return r.Before != nil
+}
+
+// Anchor returns the cursor the page starts from, if any
+func (r Request) Anchor() *Cursor {
+	if r.Before != nil {
+		return r.Before
+	}
+	return r.After
+}
+
+// ParseRequest reads the limit, after and before query parameters
+func ParseRequest(c *gin.Context) (Request, error) {
+	req := Request{Limit: DefaultLimit}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Request{}, problem.BadRequest("invalid_limit", "limit must be between 1 and "+strconv.Itoa(MaxLimit))
+		}
+		req.Limit = limit
+	}
+
+	afterStr, beforeStr := c.Query("after"), c.Query("before")
+	if afterStr != "" && beforeStr != "" {
+		return Request{}, problem.BadRequest("invalid_page", "after and before cannot be used together")
+	}
+
+	if afterStr != "" {
+		cursor, err := DecodeCursor(afterStr)
+		if err != nil {
+			return Request{}, err
+		}
+		req.After = &cursor
+	}
+
+	if beforeStr != "" {
+		cursor, err := DecodeCursor(beforeStr)
+		if err != nil {
+			return Request{}, err
+		}
+		req.Before = &cursor
+	}
+
+	return req, nil
+}
+
+// Cursors returns the next and previous cursors for a page of n items in listing order.
+// hasMore reports whether the store holds more items beyond the page in the read direction.
+func (r Request) Cursors(n int, hasMore bool, cursorAt func(i int) Cursor) (next, prev string) {
+	if n == 0 {
+		return "", ""
+	}
+
+	if r.Backward() {
+		next = cursorAt(n - 1).Encode()
+		if hasMore {
+			prev = cursorAt(0).Encode()
+		}
+		return next, prev
+	}
+
+	if hasMore {
+		next = cursorAt(n - 1).Encode()
+	}
+	if r.After != nil {
+		prev = cursorAt(0).Encode()
+	}
+	return next, prev
+}
@@ -0,0 +1,34 @@
+package pagination
+
+// Slice cuts the requested page out of items held in memory and sorted in listing order.
+// compare places an item relative to a cursor in listing order: negative when the item comes
+// first, positive when it comes after, zero when the item is the one the cursor points at.
+// It reports whether more items exist beyond the page in the read direction, as stores do.
+func Slice[T any](items []T, page Request, compare func(item T, cursor Cursor) int) ([]T, bool) {
+	start, end := 0, len(items)
+	if page.After != nil {
+		for start < end && compare(items[start], *page.After) <= 0 {
+			start++
+		}
+	}
+	if page.Before != nil {
+		for end > start && compare(items[end-1], *page.Before) >= 0 {
+			end--
+		}
+	}
+
+	// Backward pages end at the cursor, forward pages start at it
+	if page.Backward() {
+		hasMore := end-start > page.Limit
+		if hasMore {
+			start = end - page.Limit
+		}
+		return append([]T{}, items[start:end]...), hasMore
+	}
+
+	hasMore := end-start > page.Limit
+	if hasMore {
+		end = start + page.Limit
+	}
+	return append([]T{}, items[start:end]...), hasMore
+}
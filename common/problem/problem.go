@@ -0,0 +1,133 @@
+// Package problem answers errors with RFC 7807 problem details, application/problem+json,
+// carrying a stable machine-readable code clients can branch on.
+package problem
+
+import (
+	"errors"
+	"hornet/common/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of RFC 7807 problem details
+const ContentType = "application/problem+json"
+
+// Kind classifies an Error, and selects the HTTP status it is answered with
+type Kind int
+
+const (
+	KindBadRequest      Kind = iota // The request is malformed
+	KindValidation                  // A field of the request is semantically invalid
+	KindUnauthenticated             // The request carries no valid credentials
+	KindForbidden                   // The user is not allowed to perform the action
+	KindNotFound                    // The resource does not exist
+	KindConflict                    // The request conflicts with the current state of the resource
+)
+
+// statuses maps each kind of error to its HTTP status
+var statuses = map[Kind]int{
+	KindBadRequest:      http.StatusBadRequest,
+	KindValidation:      http.StatusUnprocessableEntity,
+	KindUnauthenticated: http.StatusUnauthorized,
+	KindForbidden:       http.StatusForbidden,
+	KindNotFound:        http.StatusNotFound,
+	KindConflict:        http.StatusConflict,
+}
+
+// Error is a domain error whose detail is safe to show to clients.
+// Services declare them as sentinels, compared with errors.Is, or build them per request.
+type Error struct {
+	Kind   Kind
+	Code   string // Stable machine-readable code, e.g. post_not_found
+	Detail string // Human-readable explanation of this occurrence
+	Field  string // The request field at fault, set on validation errors
+}
+
+func (e *Error) Error() string {
+	if e.Field != "" {
+		return e.Field + ": " + e.Detail
+	}
+	return e.Detail
+}
+
+// Status returns the HTTP status the error is answered with
+func (e *Error) Status() int {
+	return statuses[e.Kind]
+}
+
+// BadRequest returns an Error for a malformed request
+func BadRequest(code, detail string) *Error {
+	return &Error{Kind: KindBadRequest, Code: code, Detail: detail}
+}
+
+// Validation returns an Error for a semantically invalid field of a request
+func Validation(code, field, detail string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Detail: detail, Field: field}
+}
+
+// Unauthenticated returns an Error for a request without valid credentials
+func Unauthenticated(code, detail string) *Error {
+	return &Error{Kind: KindUnauthenticated, Code: code, Detail: detail}
+}
+
+// Forbidden returns an Error for an action the user is not allowed to perform
+func Forbidden(code, detail string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Detail: detail}
+}
+
+// NotFound returns an Error for a resource that does not exist
+func NotFound(code, detail string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Detail: detail}
+}
+
+// Conflict returns an Error for a request conflicting with the state of a resource
+func Conflict(code, detail string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Detail: detail}
+}
+
+// Details is the RFC 7807 body of an error response, extended with a code and a field
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Field    string `json:"field,omitempty"`
+}
+
+// InternalCode is the code of every error that is not an *Error, whose detail is hidden from clients
+const InternalCode = "internal_error"
+
+// Respond logs err, writes its problem details and aborts the request.
+// An *Error anywhere in the chain of err is answered with its status and detail;
+// any other error is answered with a 500 that does not reveal it.
+func Respond(c *gin.Context, err error) {
+	var problemErr *Error
+	if !errors.As(err, &problemErr) {
+		logger.WithContext(c).Error("Internal error ", "error: ", err)
+		write(c, http.StatusInternalServerError, &Error{Code: InternalCode, Detail: "An unexpected error occurred"})
+		return
+	}
+
+	logger.WithContext(c).Info("Request rejected ", problemErr.Code, " error: ", err)
+	write(c, problemErr.Status(), problemErr)
+}
+
+// write sends the problem details of an error answered with status
+func write(c *gin.Context, status int, problemErr *Error) {
+	details := Details{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   problemErr.Detail,
+		Instance: c.Request.URL.Path,
+		Code:     problemErr.Code,
+		Field:    problemErr.Field,
+	}
+
+	// Gin keeps a Content-Type already set when rendering JSON
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(status, details)
+}
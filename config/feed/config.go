@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"hornet/common/auth"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	MongoURI            string
+	DBName              string
+	ServerPort          string
+	FollowersServiceURL string
+	FanOutThreshold     int
+	Auth                auth.Config
+}
+
+func LoadConfig() *Config {
+	// Load environment variables
+	err := godotenv.Load(".env")
+	if err != nil {
+		log.Printf("Warning: .env file not found. Using system environment variables.")
+	}
+
+	// Read variables from the environment
+	mongoURI := os.Getenv("MONGO_URI")
+	dbName := os.Getenv("MONGO_DB")
+	serverPort := os.Getenv("FEED_PORT")
+	followersServiceURL := os.Getenv("FOLLOWERS_SERVICE_URL")
+
+	// Validate required variables
+	if mongoURI == "" || dbName == "" || followersServiceURL == "" {
+		log.Fatalf("Environment variables MONGO_URI, MONGO_DB and FOLLOWERS_SERVICE_URL must be set.")
+	}
+
+	// Use default port if not set
+	if serverPort == "" {
+		serverPort = "8080"
+	}
+
+	// Authors with more followers are read from their outbox instead of fanned out
+	fanOutThreshold := 10000
+	if value := os.Getenv("FEED_FANOUT_THRESHOLD"); value != "" {
+		fanOutThreshold, err = strconv.Atoi(value)
+		if err != nil || fanOutThreshold < 0 {
+			log.Fatalf("Environment variable FEED_FANOUT_THRESHOLD must be a non-negative integer, got %q.", value)
+		}
+	}
+
+	// Requests are authenticated with bearer tokens unless the mesh is explicitly trusted
+	authConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid authentication settings: %v.", err)
+	}
+
+	// Feed updates are calls only the posts service may make
+	if authConfig.ServiceToken == "" {
+		log.Fatalf("Environment variable AUTH_SERVICE_TOKEN must be set.")
+	}
+
+	return &Config{
+		MongoURI:            mongoURI,
+		DBName:              dbName,
+		ServerPort:          serverPort,
+		FollowersServiceURL: followersServiceURL,
+		FanOutThreshold:     fanOutThreshold,
+		Auth:                authConfig,
+	}
+}
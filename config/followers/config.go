@@ -1,18 +1,22 @@
 package followers
 
 import (
+	"hornet/common/auth"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Neo4jURI   string
-	DBName     string
-	User       string
-	Password   string
-	ServerPort string
+	Neo4jURI       string
+	DBName         string
+	User           string
+	Password       string
+	ServerPort     string
+	MigrateOnStart bool
+	Auth           auth.Config
 }
 
 func LoadConfig() *Config {
@@ -39,11 +43,28 @@ func LoadConfig() *Config {
 		serverPort = "8080"
 	}
 
+	// Apply pending schema migrations at startup unless they run as a separate step
+	migrateOnStart := true
+	if value := os.Getenv("NEO4J_MIGRATE_ON_START"); value != "" {
+		migrateOnStart, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Environment variable NEO4J_MIGRATE_ON_START must be a boolean, got %q.", value)
+		}
+	}
+
+	// Requests are authenticated with bearer tokens unless the mesh is explicitly trusted
+	authConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid authentication settings: %v.", err)
+	}
+
 	return &Config{
-		Neo4jURI:   neo4jURI,
-		DBName:     dbName,
-		ServerPort: serverPort,
-		User:       user,
-		Password:   password,
+		Neo4jURI:       neo4jURI,
+		DBName:         dbName,
+		ServerPort:     serverPort,
+		User:           user,
+		Password:       password,
+		MigrateOnStart: migrateOnStart,
+		Auth:           authConfig,
 	}
 }
@@ -1,16 +1,26 @@
 package posts
 
 import (
+	"hornet/common/auth"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	MongoURI   string
-	DBName     string
-	ServerPort string
+	MongoURI            string
+	DBName              string
+	ServerPort          string
+	FollowersServiceURL string
+	FeedServiceURL      string
+	FeedRetryDelay      time.Duration
+	RestoreWindow       time.Duration
+	PurgeInterval       time.Duration
+	EditWindow          time.Duration
+	BlockCacheTTL       time.Duration
+	Auth                auth.Config
 }
 
 func LoadConfig() *Config {
@@ -24,10 +34,11 @@ func LoadConfig() *Config {
 	mongoURI := os.Getenv("MONGO_URI")
 	dbName := os.Getenv("MONGO_DB")
 	serverPort := os.Getenv("POSTS_PORT")
+	followersServiceURL := os.Getenv("FOLLOWERS_SERVICE_URL")
 
 	// Validate required variables
-	if mongoURI == "" || dbName == "" {
-		log.Fatalf("Environment variables MONGO_URI, MONGO_DB, and must be set.")
+	if mongoURI == "" || dbName == "" || followersServiceURL == "" {
+		log.Fatalf("Environment variables MONGO_URI, MONGO_DB and FOLLOWERS_SERVICE_URL must be set.")
 	}
 
 	// Use default port if not set
@@ -35,9 +46,42 @@ func LoadConfig() *Config {
 		serverPort = "8080"
 	}
 
+	// Requests are authenticated with bearer tokens unless the mesh is explicitly trusted
+	authConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid authentication settings: %v.", err)
+	}
+
+	// Block checks, and feed updates when enabled, are calls only other services may make
+	if authConfig.ServiceToken == "" {
+		log.Fatalf("Environment variable AUTH_SERVICE_TOKEN must be set.")
+	}
+
 	return &Config{
-		MongoURI:   mongoURI,
-		DBName:     dbName,
-		ServerPort: serverPort,
+		MongoURI:            mongoURI,
+		DBName:              dbName,
+		ServerPort:          serverPort,
+		FollowersServiceURL: followersServiceURL,
+		FeedServiceURL:      os.Getenv("FEED_SERVICE_URL"),
+		FeedRetryDelay:      durationEnv("FEED_RETRY_DELAY", 5*time.Second),
+		RestoreWindow:       durationEnv("POST_RESTORE_WINDOW", 30*24*time.Hour),
+		PurgeInterval:       durationEnv("POST_PURGE_INTERVAL", time.Hour),
+		EditWindow:          durationEnv("POST_EDIT_WINDOW", time.Hour),
+		BlockCacheTTL:       durationEnv("BLOCK_CACHE_TTL", 30*time.Second),
+		Auth:                authConfig,
+	}
+}
+
+// durationEnv reads a duration such as "72h" from the environment, falling back to a default
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Fatalf("Environment variable %s must be a positive duration, got %q.", key, value)
 	}
+	return duration
 }